@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marcosalvi-01/wallman/db"
+
+	"github.com/spf13/cobra"
+)
+
+var banCmd = &cobra.Command{
+	Use:   "ban [path]",
+	Short: "Ban a wallpaper",
+	Long:  `Bans a wallpaper, defaulting to the current one, so that next and random never pick it again. It can still be set explicitly with set. Use --remove to lift the ban.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, _ := cmd.Flags().GetBool("remove")
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		paths, err := targetPaths(args, monitor)
+		if err != nil {
+			return err
+		}
+		path := paths[0]
+
+		err = db.SetBanned(path, !remove)
+		if err != nil {
+			return err
+		}
+
+		if remove {
+			fmt.Printf("Unbanned %s\n", path)
+		} else {
+			fmt.Printf("Banned %s\n", path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(banCmd)
+	banCmd.Flags().Bool("remove", false, "Lift the ban")
+	banCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marcosalvi-01/wallman/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of processed wallpapers",
+	Long:  `Manages the images made by the pipelines and by spanning, cached in ~/.local/share/wallman/cache. The cache drops the least recently used images by itself once it outgrows 512 MiB.`,
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Delete the processed wallpapers",
+	Long:  `Deletes every cached image. They are made again when needed.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, freed, err := pipeline.CleanCache()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached images (%.1f MiB)\n", removed, float64(freed)/(1<<20))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheCleanCmd)
+}
@@ -1,11 +1,159 @@
 package common
 
-import "regexp"
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
 
-// ImageRegex matches common image file extensions
-var ImageRegex = regexp.MustCompile(`^.*\.(jpe?g|png|bmp|webp)$`)
+// Supported image formats, as reported by Sniff.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatBMP  = "bmp"
+	FormatWEBP = "webp"
+)
 
-// IsImage checks if a file name corresponds to a supported image format
+// imageExtensions are the extensions of the files worth sniffing.
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".bmp":  true,
+	".webp": true,
+}
+
+// ErrNotImage is returned by Sniff for files that are not a supported image.
+var ErrNotImage = errors.New("unsupported image format (only JPEG, PNG, BMP, WEBP are supported)")
+
+// ImageInfo describes an image file.
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+}
+
+// IsImage reports whether a file name has the extension of a supported image
+// format, in any case. It is a cheap filter, Sniff tells what the file really is.
 func IsImage(fileName string) bool {
-	return ImageRegex.Match([]byte(fileName))
+	return imageExtensions[strings.ToLower(filepath.Ext(fileName))]
+}
+
+// Sniff identifies the image at path from its content rather than its name and
+// reads its dimensions from the header. Files that are not a supported image,
+// or whose header is corrupt, return an error wrapping ErrNotImage.
+func Sniff(path string) (ImageInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	defer file.Close()
+
+	return SniffReader(file)
+}
+
+// SniffReader is Sniff for an image being read from r.
+func SniffReader(r io.Reader) (ImageInfo, error) {
+	// Every header handled by hand fits in the first 32 bytes, the decoders
+	// get them back in front of the rest of the file.
+	header := make([]byte, 32)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return ImageInfo{}, fmt.Errorf("%w: %w", ErrNotImage, err)
+	}
+	header = header[:n]
+
+	var info ImageInfo
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		config, err := jpeg.DecodeConfig(io.MultiReader(bytes.NewReader(header), r))
+		if err != nil {
+			return ImageInfo{}, fmt.Errorf("%w: corrupt JPEG: %w", ErrNotImage, err)
+		}
+		info = ImageInfo{Format: FormatJPEG, Width: config.Width, Height: config.Height}
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		config, err := png.DecodeConfig(io.MultiReader(bytes.NewReader(header), r))
+		if err != nil {
+			return ImageInfo{}, fmt.Errorf("%w: corrupt PNG: %w", ErrNotImage, err)
+		}
+		info = ImageInfo{Format: FormatPNG, Width: config.Width, Height: config.Height}
+	case bytes.HasPrefix(header, []byte("BM")):
+		info, err = sniffBMP(header)
+	case len(header) >= 12 && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		info, err = sniffWEBP(header)
+	default:
+		return ImageInfo{}, ErrNotImage
+	}
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	if info.Width <= 0 || info.Height <= 0 {
+		return ImageInfo{}, fmt.Errorf("%w: invalid %s dimensions %dx%d", ErrNotImage, info.Format, info.Width, info.Height)
+	}
+	return info, nil
+}
+
+func sniffBMP(header []byte) (ImageInfo, error) {
+	if len(header) < 26 {
+		return ImageInfo{}, fmt.Errorf("%w: truncated BMP header", ErrNotImage)
+	}
+
+	info := ImageInfo{Format: FormatBMP}
+	switch dibSize := binary.LittleEndian.Uint32(header[14:18]); {
+	case dibSize == 12:
+		// BITMAPCOREHEADER
+		info.Width = int(binary.LittleEndian.Uint16(header[18:20]))
+		info.Height = int(binary.LittleEndian.Uint16(header[20:22]))
+	case dibSize >= 40:
+		info.Width = int(int32(binary.LittleEndian.Uint32(header[18:22])))
+		// Top-down bitmaps have a negative height.
+		info.Height = int(int32(binary.LittleEndian.Uint32(header[22:26])))
+		if info.Height < 0 {
+			info.Height = -info.Height
+		}
+	default:
+		return ImageInfo{}, fmt.Errorf("%w: unknown BMP header size %d", ErrNotImage, dibSize)
+	}
+	return info, nil
+}
+
+func sniffWEBP(header []byte) (ImageInfo, error) {
+	if len(header) < 30 {
+		return ImageInfo{}, fmt.Errorf("%w: truncated WEBP header", ErrNotImage)
+	}
+
+	info := ImageInfo{Format: FormatWEBP}
+	switch chunk := string(header[12:16]); chunk {
+	case "VP8 ":
+		// Lossy: a key frame starts with a start code followed by the 14 bit
+		// dimensions.
+		if !bytes.Equal(header[23:26], []byte{0x9d, 0x01, 0x2a}) {
+			return ImageInfo{}, fmt.Errorf("%w: corrupt WEBP frame", ErrNotImage)
+		}
+		info.Width = int(binary.LittleEndian.Uint16(header[26:28]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(header[28:30]) & 0x3fff)
+	case "VP8L":
+		// Lossless: a signature byte followed by the 14 bit dimensions minus one.
+		if header[20] != 0x2f {
+			return ImageInfo{}, fmt.Errorf("%w: corrupt WEBP lossless header", ErrNotImage)
+		}
+		bits := binary.LittleEndian.Uint32(header[21:25])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		// Extended: the 24 bit canvas dimensions minus one.
+		info.Width = int(uint32(header[24])|uint32(header[25])<<8|uint32(header[26])<<16) + 1
+		info.Height = int(uint32(header[27])|uint32(header[28])<<8|uint32(header[29])<<16) + 1
+	default:
+		return ImageInfo{}, fmt.Errorf("%w: unknown WEBP chunk %q", ErrNotImage, chunk)
+	}
+	return info, nil
 }
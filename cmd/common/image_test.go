@@ -0,0 +1,131 @@
+package common_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+)
+
+func encoded(t *testing.T, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// riff wraps a WEBP chunk in its RIFF container, padded to a full header.
+func riff(chunk string, payload ...byte) []byte {
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"+chunk+"\x00\x00\x00\x00"), payload...)
+	return append(data, make([]byte, 32)...)
+}
+
+func TestSniff(t *testing.T) {
+	bmp := make([]byte, 54)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[14:], 40)
+	binary.LittleEndian.PutUint32(bmp[18:], 3)
+	binary.LittleEndian.PutUint32(bmp[22:], uint32(0xffffffff-1)) // -2, top-down
+
+	lossless := make([]byte, 5)
+	lossless[0] = 0x2f
+	binary.LittleEndian.PutUint32(lossless[1:], 2|1<<14)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    common.ImageInfo
+		wantErr bool
+	}{
+		{
+			name: "jpeg",
+			data: encoded(t, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) }),
+			want: common.ImageInfo{Format: common.FormatJPEG, Width: 3, Height: 2},
+		},
+		{
+			name: "png",
+			data: encoded(t, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) }),
+			want: common.ImageInfo{Format: common.FormatPNG, Width: 3, Height: 2},
+		},
+		{
+			name: "bmp",
+			data: bmp,
+			want: common.ImageInfo{Format: common.FormatBMP, Width: 3, Height: 2},
+		},
+		{
+			name: "lossy webp",
+			data: riff("VP8 ", 0, 0, 0, 0x9d, 0x01, 0x2a, 3, 0, 2, 0),
+			want: common.ImageInfo{Format: common.FormatWEBP, Width: 3, Height: 2},
+		},
+		{
+			name: "lossless webp",
+			data: riff("VP8L", lossless...),
+			want: common.ImageInfo{Format: common.FormatWEBP, Width: 3, Height: 2},
+		},
+		{
+			name: "extended webp",
+			data: riff("VP8X", 0, 0, 0, 0, 2, 0, 0, 1, 0, 0),
+			want: common.ImageInfo{Format: common.FormatWEBP, Width: 3, Height: 2},
+		},
+		{
+			name:    "text",
+			data:    []byte("definitely not an image"),
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "corrupt png",
+			data:    []byte("\x89PNG\r\n\x1a\n garbage after the signature"),
+			wantErr: true,
+		},
+		{
+			name:    "corrupt webp",
+			data:    riff("VP8 ", 0, 0, 0, 1, 2, 3),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := common.SniffReader(bytes.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SniffReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, common.ErrNotImage) {
+				t.Errorf("SniffReader() error = %v, want it to wrap ErrNotImage", err)
+			}
+			if got != tt.want {
+				t.Errorf("SniffReader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a.png", true},
+		{"IMG_001.JPG", true},
+		{"photo.Jpeg", true},
+		{"wall.webp", true},
+		{"notes.txt", false},
+		{"png", false},
+	}
+	for _, tt := range tests {
+		if got := common.IsImage(tt.name); got != tt.want {
+			t.Errorf("IsImage(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,44 +0,0 @@
-package common
-
-import (
-	"fmt"
-	"io/fs"
-	"os"
-	"path"
-	"path/filepath"
-)
-
-func List(dirs []string, subdirs bool) ([]string, error) {
-	walls := make([]string, 0)
-
-	for _, wallpaperDir := range dirs {
-		if subdirs {
-			if err := filepath.WalkDir(wallpaperDir, func(dirPath string, d fs.DirEntry, err error) error {
-				if err != nil {
-					return nil
-				}
-				if d.IsDir() || !IsImage(d.Name()) {
-					return nil
-				}
-				walls = append(walls, dirPath)
-				return nil
-			}); err != nil {
-				return nil, fmt.Errorf("failed to walk directory %s: %w", wallpaperDir, err)
-			}
-		} else {
-			entries, err := os.ReadDir(wallpaperDir)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read wallpaper directory %s: %w", wallpaperDir, err)
-			}
-
-			for _, entry := range entries {
-				if entry.IsDir() || !IsImage(entry.Name()) {
-					continue
-				}
-				walls = append(walls, path.Join(wallpaperDir, entry.Name()))
-			}
-		}
-	}
-
-	return walls, nil
-}
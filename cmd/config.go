@@ -4,23 +4,170 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/colorscheme"
+	"github.com/marcosalvi-01/wallman/command"
+	"github.com/marcosalvi-01/wallman/daemon"
 	"github.com/marcosalvi-01/wallman/db"
 	"github.com/marcosalvi-01/wallman/db/sqlc"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/library"
+	"github.com/marcosalvi-01/wallman/palette"
+	"github.com/marcosalvi-01/wallman/pipeline"
+	"github.com/marcosalvi-01/wallman/schedule"
+	"github.com/marcosalvi-01/wallman/swww"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	monitorModeShared      = "shared"
+	monitorModeIndependent = "independent"
+	monitorModeSpan        = "span"
+)
+
 var (
-	appConfig  *Config
-	cfgFile    string
-	appQueries *sqlc.Queries
+	appConfig     *Config
+	appConfigPath string
+	cfgFile       string
+	appQueries    *sqlc.Queries
 )
 
 type Config struct {
 	WallpaperDirs []string `yaml:"wallpaper_directories"`
 	TravelSubDirs bool     `yaml:"travel_sub_directories"`
 	Manager       string   `yaml:"manager"`
+	// MonitorMode is "shared" (one wallpaper on every monitor) or
+	// "independent" (each monitor gets its own selection) or "span" (one
+	// wallpaper sliced across the monitors).
+	MonitorMode string       `yaml:"monitor_mode"`
+	Daemon      DaemonConfig `yaml:"daemon"`
+	// Swww holds the transition settings of the swww manager.
+	Swww swww.Options `yaml:"swww"`
+	// Command holds the templates of the command manager.
+	Command command.Options `yaml:"command"`
+	// FavoritesOnly restricts next and random to favorite wallpapers.
+	FavoritesOnly bool `yaml:"favorites_only"`
+	// Tags restricts next and random to wallpapers having all of these tags.
+	Tags []string `yaml:"tags"`
+	// AutoTags tags wallpapers with the names of their subdirectories when
+	// travel_sub_directories is on.
+	AutoTags bool `yaml:"auto_tags"`
+	// RandomStrategy is the strategy random uses by default, see engine.Strategies.
+	RandomStrategy string `yaml:"random_strategy"`
+	// RandomRecentDays is how far back the avoid-recent strategy looks.
+	RandomRecentDays int `yaml:"random_recent_days"`
+	// ScanInterval is how old the library index may get before commands
+	// rescan the wallpaper directories, as a Go duration or "never".
+	ScanInterval string `yaml:"scan_interval"`
+	// Match controls how strictly next and random pick wallpapers fitting
+	// the size and orientation of each monitor.
+	Match engine.Match `yaml:"match"`
+	// Fit is how wallpapers are fitted to the monitors, see engine.Fits. If
+	// empty next, previous and random crop (cover) while set and
+	// --true-random stretch (fill).
+	Fit string `yaml:"fit"`
+	// FitDirectories overrides fit for the wallpapers inside a directory.
+	FitDirectories map[string]string `yaml:"fit_directories"`
+	// FitMonitors overrides fit and fit_directories on a monitor.
+	FitMonitors map[string]string `yaml:"fit_monitors"`
+	// Pipelines are named image pipelines wallpapers can go through before
+	// they are shown.
+	Pipelines map[string]pipeline.Pipeline `yaml:"pipelines"`
+	// Pipeline names the pipeline of every wallpaper, none if empty.
+	Pipeline string `yaml:"pipeline"`
+	// PipelineTags overrides pipeline for the wallpapers having a tag.
+	PipelineTags map[string]string `yaml:"pipeline_tags"`
+	// PipelineMonitors overrides pipeline and pipeline_tags on a monitor.
+	PipelineMonitors map[string]string `yaml:"pipeline_monitors"`
+	// Palette configures the color palettes extracted from the wallpapers.
+	Palette PaletteConfig `yaml:"palette"`
+	// Hooks are shell commands run around every wallpaper change.
+	Hooks HooksConfig `yaml:"hooks"`
+	// Schedule restricts next and random to other wallpapers depending on
+	// the time, the first active rule wins.
+	Schedule schedule.Schedule `yaml:"schedule"`
+	// Sun switches between day and night wallpapers, and between the light
+	// and dark variants of the same wallpaper, at sunrise and sunset.
+	Sun *SunConfig `yaml:"sun"`
+	// ColorScheme switches between light and dark wallpapers, and between
+	// the variants of the same wallpaper, following the desktop preference.
+	ColorScheme *ColorSchemeConfig `yaml:"color_scheme"`
+	// Workspaces maps Hyprland workspace names to the wallpaper the daemon
+	// shows when a monitor switches to them. 'wallman workspace set' wins.
+	Workspaces map[string]string `yaml:"workspaces"`
+}
+
+// fitUsage is the usage of the --fit flags.
+const fitUsage = "Fit mode: contain, cover, tile or fill (overrides fit and its overrides)"
+
+// DaemonConfig configures the rotation done by `wallman daemon`.
+type DaemonConfig struct {
+	// Interval between two rotations, as a Go duration such as "30m".
+	Interval string `yaml:"interval"`
+	// Action is the rotation performed on every interval: "random" or "next".
+	Action string `yaml:"action"`
+	// Watch keeps the library index up to date while the daemon runs, see
+	// `wallman watch`.
+	Watch bool `yaml:"watch"`
+}
+
+// HooksConfig lists the shell commands run around wallpaper changes, see
+// engine.Hooks for their environment.
+type HooksConfig struct {
+	PreSet  []string `yaml:"pre_set"`
+	PostSet []string `yaml:"post_set"`
+	// Timeout is how long a hook may run, as a Go duration, 10s if empty.
+	Timeout string `yaml:"timeout"`
+}
+
+// EngineHooks returns the hooks of the config.
+func (c *Config) EngineHooks() engine.Hooks {
+	// validateConfig already checked the duration.
+	timeout, _ := time.ParseDuration(c.Hooks.Timeout)
+	return engine.Hooks{
+		PreSet:  c.Hooks.PreSet,
+		PostSet: c.Hooks.PostSet,
+		Timeout: timeout,
+	}
+}
+
+// scanIntervalNever only scans the wallpaper directories on `wallman scan`.
+const scanIntervalNever = "never"
+
+// defaultScanInterval is used when scan_interval is not set.
+const defaultScanInterval = 10 * time.Minute
+
+// LibraryOptions returns the options of the library index.
+func (c *Config) LibraryOptions() library.Options {
+	maxAge := defaultScanInterval
+	switch c.ScanInterval {
+	case "":
+	case scanIntervalNever:
+		maxAge = -1
+	default:
+		// validateConfig already checked the duration.
+		maxAge, _ = time.ParseDuration(c.ScanInterval)
+	}
+	return library.Options{
+		Dirs:      c.WallpaperDirs,
+		Recursive: c.TravelSubDirs,
+		MaxAge:    maxAge,
+	}
+}
+
+// Independent reports whether each monitor should get its own wallpaper.
+func (c *Config) Independent() bool {
+	return c.MonitorMode == monitorModeIndependent
+}
+
+// Span reports whether the shared wallpaper should be sliced across the
+// monitors.
+func (c *Config) Span() bool {
+	return c.MonitorMode == monitorModeSpan
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -43,6 +190,11 @@ func defaultConfig() *Config {
 		WallpaperDirs: []string{},
 		TravelSubDirs: false,
 		Manager:       "auto",
+		MonitorMode:   monitorModeShared,
+		Daemon: DaemonConfig{
+			Interval: "30m",
+			Action:   daemon.ActionRandom,
+		},
 	}
 }
 
@@ -57,6 +209,7 @@ func initConfig() {
 	}
 
 	configPath := findConfigPath(cfgFile, homeDir)
+	appConfigPath = configPath
 	if configPath != "" {
 		loaded, err := loadConfig(configPath)
 		if err != nil {
@@ -69,15 +222,7 @@ func initConfig() {
 		config = defaultConfig()
 	}
 
-	// Expand paths
-	expandedDirs := make([]string, len(config.WallpaperDirs))
-	for i, dir := range config.WallpaperDirs {
-		expandedDirs[i] = common.ExpandPath(dir)
-	}
-	config.WallpaperDirs = expandedDirs
-
-	// Validate
-	if err := validateConfig(config); err != nil {
+	if err := prepareConfig(config); err != nil {
 		fmt.Fprintf(os.Stderr, "Config validation failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -92,6 +237,66 @@ func initConfig() {
 	}
 }
 
+// reloadConfig reads the config file found on startup again. Unlike
+// initConfig it reports errors instead of falling back to the defaults.
+func reloadConfig() (*Config, error) {
+	config := defaultConfig()
+	if appConfigPath != "" {
+		loaded, err := loadConfig(appConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	}
+
+	if err := prepareConfig(config); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	return config, nil
+}
+
+// prepareConfig expands the paths of config and validates it.
+func prepareConfig(config *Config) error {
+	config.WallpaperDirs = expandPaths(config.WallpaperDirs)
+
+	for i, rule := range config.Schedule {
+		config.Schedule[i].Directories = expandPaths(rule.Directories)
+	}
+	if config.Sun != nil {
+		config.Sun.Day.Directories = expandPaths(config.Sun.Day.Directories)
+		config.Sun.Night.Directories = expandPaths(config.Sun.Night.Directories)
+	}
+	if config.ColorScheme != nil {
+		config.ColorScheme.Light.Directories = expandPaths(config.ColorScheme.Light.Directories)
+		config.ColorScheme.Dark.Directories = expandPaths(config.ColorScheme.Dark.Directories)
+	}
+
+	for i, output := range config.Palette.Outputs {
+		config.Palette.Outputs[i].Path = common.ExpandPath(output.Path)
+	}
+	for workspace, path := range config.Workspaces {
+		config.Workspaces[workspace] = common.ExpandPath(path)
+	}
+
+	if len(config.FitDirectories) > 0 {
+		expandedFits := make(map[string]string, len(config.FitDirectories))
+		for dir, fit := range config.FitDirectories {
+			expandedFits[common.ExpandPath(dir)] = fit
+		}
+		config.FitDirectories = expandedFits
+	}
+
+	return validateConfig(config)
+}
+
+func expandPaths(paths []string) []string {
+	expanded := make([]string, len(paths))
+	for i, path := range paths {
+		expanded[i] = common.ExpandPath(path)
+	}
+	return expanded
+}
+
 func findConfigPath(cfgFile, homeDir string) string {
 	if cfgFile != "" {
 		return cfgFile
@@ -121,6 +326,175 @@ func validateConfig(config *Config) error {
 			return fmt.Errorf("wallpaper directory does not exist: %s", dir)
 		}
 	}
+	if err := config.Schedule.Validate(); err != nil {
+		return err
+	}
+	for _, rule := range config.Schedule {
+		for _, dir := range rule.Directories {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				return fmt.Errorf("schedule directory does not exist: %s", dir)
+			}
+		}
+	}
+	if err := validateSun(config.Sun); err != nil {
+		return err
+	}
+	if err := validateColorScheme(config.ColorScheme); err != nil {
+		return err
+	}
+	for workspace, path := range config.Workspaces {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("wallpaper of workspace %s does not exist: %s", workspace, path)
+		}
+	}
+	switch config.MonitorMode {
+	case "", monitorModeShared, monitorModeIndependent, monitorModeSpan:
+	default:
+		return fmt.Errorf("invalid monitor_mode %q (expected %q, %q or %q)", config.MonitorMode, monitorModeShared, monitorModeIndependent, monitorModeSpan)
+	}
+	if config.Daemon.Interval != "" {
+		if _, err := time.ParseDuration(config.Daemon.Interval); err != nil {
+			return fmt.Errorf("invalid daemon interval %q: %w", config.Daemon.Interval, err)
+		}
+	}
+	if config.Hooks.Timeout != "" {
+		if timeout, err := time.ParseDuration(config.Hooks.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid hooks timeout %q (expected a positive duration)", config.Hooks.Timeout)
+		}
+	}
+	switch config.Daemon.Action {
+	case "", daemon.ActionRandom, daemon.ActionNext:
+	default:
+		return fmt.Errorf("invalid daemon action %q (expected %q or %q)", config.Daemon.Action, daemon.ActionRandom, daemon.ActionNext)
+	}
+	if config.RandomStrategy != "" && !engine.ValidStrategy(config.RandomStrategy) {
+		return fmt.Errorf("invalid random_strategy %q (expected one of %s)", config.RandomStrategy, strings.Join(engine.Strategies, ", "))
+	}
+	if config.ScanInterval != "" && config.ScanInterval != scanIntervalNever {
+		if interval, err := time.ParseDuration(config.ScanInterval); err != nil || interval < 0 {
+			return fmt.Errorf("invalid scan_interval %q (expected a duration or %q)", config.ScanInterval, scanIntervalNever)
+		}
+	}
+	if config.Match.Mode != "" && !slices.Contains(engine.MatchModes, config.Match.Mode) {
+		return fmt.Errorf("invalid match mode %q (expected one of %s)", config.Match.Mode, strings.Join(engine.MatchModes, ", "))
+	}
+	if config.Match.AspectTolerance < 0 {
+		return fmt.Errorf("invalid match aspect_tolerance %g (must not be negative)", config.Match.AspectTolerance)
+	}
+	if config.Match.MinResolution < 0 {
+		return fmt.Errorf("invalid match min_resolution %g (must not be negative)", config.Match.MinResolution)
+	}
+	if err := validateFit("fit", config.Fit); err != nil {
+		return err
+	}
+	for dir, fit := range config.FitDirectories {
+		if err := validateFit("fit_directories "+dir, fit); err != nil {
+			return err
+		}
+	}
+	for monitor, fit := range config.FitMonitors {
+		if err := validateFit("fit_monitors "+monitor, fit); err != nil {
+			return err
+		}
+	}
+	if err := validatePipelines(config); err != nil {
+		return err
+	}
+	if err := validatePalette(config.Palette); err != nil {
+		return err
+	}
+	if config.RandomRecentDays < 0 {
+		return fmt.Errorf("invalid random_recent_days %d (must not be negative)", config.RandomRecentDays)
+	}
+	return nil
+}
+
+func validateFit(key, fit string) error {
+	if fit != "" && !engine.ValidFit(fit) {
+		return fmt.Errorf("invalid %s %q (expected one of %s)", key, fit, strings.Join(engine.Fits, ", "))
+	}
+	return nil
+}
+
+func validatePipelines(config *Config) error {
+	for name, p := range config.Pipelines {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid pipeline %s: %w", name, err)
+		}
+	}
+
+	check := func(key, name string) error {
+		if _, ok := config.Pipelines[name]; name != "" && !ok {
+			return fmt.Errorf("invalid %s: unknown pipeline %q", key, name)
+		}
+		return nil
+	}
+	if err := check("pipeline", config.Pipeline); err != nil {
+		return err
+	}
+	for tag, name := range config.PipelineTags {
+		if err := check("pipeline_tags "+tag, name); err != nil {
+			return err
+		}
+	}
+	for monitor, name := range config.PipelineMonitors {
+		if err := check("pipeline_monitors "+monitor, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateSun(config *SunConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Latitude < -90 || config.Latitude > 90 {
+		return fmt.Errorf("invalid sun latitude %g (expected -90 to 90)", config.Latitude)
+	}
+	if config.Longitude < -180 || config.Longitude > 180 {
+		return fmt.Errorf("invalid sun longitude %g (expected -180 to 180)", config.Longitude)
+	}
+	for _, dir := range slices.Concat(config.Day.Directories, config.Night.Directories) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("sun directory does not exist: %s", dir)
+		}
+	}
+	return nil
+}
+
+func validateColorScheme(config *ColorSchemeConfig) error {
+	if config == nil {
+		return nil
+	}
+	if _, err := colorscheme.New(config.Source); err != nil {
+		return err
+	}
+	for _, dir := range slices.Concat(config.Light.Directories, config.Dark.Directories) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("color scheme directory does not exist: %s", dir)
+		}
+	}
+	return nil
+}
+
+func validatePalette(config PaletteConfig) error {
+	if config.Colors < 0 || config.Colors > 64 {
+		return fmt.Errorf("invalid palette colors %d (expected 1 to 64)", config.Colors)
+	}
+	for name := range config.Formats {
+		if _, err := palette.Parse(name, config.Formats); err != nil {
+			return err
+		}
+	}
+	for _, output := range config.Outputs {
+		if output.Path == "" {
+			return fmt.Errorf("invalid palette output: missing path")
+		}
+		if _, err := palette.Parse(output.Format, config.Formats); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
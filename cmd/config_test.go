@@ -7,6 +7,11 @@ import (
 	"testing"
 
 	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/pipeline"
+	"github.com/marcosalvi-01/wallman/schedule"
+	"github.com/marcosalvi-01/wallman/sun"
 )
 
 func TestExpandPath(t *testing.T) {
@@ -41,6 +46,44 @@ func TestValidateConfig(t *testing.T) {
 		{"valid dirs", &Config{WallpaperDirs: []string{tempDir}}, false},
 		{"non-existent dir", &Config{WallpaperDirs: []string{nonExistent}}, true},
 		{"empty dirs", &Config{WallpaperDirs: []string{}}, false},
+		{"independent monitors", &Config{MonitorMode: "independent"}, false},
+		{"span monitors", &Config{MonitorMode: "span"}, false},
+		{"invalid monitor mode", &Config{MonitorMode: "mirrored"}, true},
+		{"weighted random", &Config{RandomStrategy: "weighted"}, false},
+		{"invalid random strategy", &Config{RandomStrategy: "sometimes"}, true},
+		{"scan interval", &Config{ScanInterval: "1h"}, false},
+		{"never scan", &Config{ScanInterval: "never"}, false},
+		{"invalid scan interval", &Config{ScanInterval: "sometimes"}, true},
+		{"strict match", &Config{Match: engine.Match{Mode: "strict", AspectTolerance: 0.1}}, false},
+		{"invalid match mode", &Config{Match: engine.Match{Mode: "exact"}}, true},
+		{"fit", &Config{Fit: "contain"}, false},
+		{"invalid fit", &Config{Fit: "stretch"}, true},
+		{"fit overrides", &Config{FitDirectories: map[string]string{tempDir: "tile"}, FitMonitors: map[string]string{"DP-1": "fill"}}, false},
+		{"invalid monitor fit", &Config{FitMonitors: map[string]string{"DP-1": "zoom"}}, true},
+		{"pipeline", &Config{Pipelines: map[string]pipeline.Pipeline{"soft": {Resize: true, Blur: 4, Dim: 0.3, Tint: "#102030"}}, PipelineMonitors: map[string]string{"DP-1": "soft"}}, false},
+		{"invalid pipeline", &Config{Pipelines: map[string]pipeline.Pipeline{"dark": {Dim: 2}}}, true},
+		{"invalid tint", &Config{Pipelines: map[string]pipeline.Pipeline{"red": {Tint: "red"}}}, true},
+		{"unknown pipeline", &Config{PipelineTags: map[string]string{"night": "soft"}}, true},
+		{"negative min resolution", &Config{Match: engine.Match{MinResolution: -1}}, true},
+		{"palette", &Config{Palette: PaletteConfig{Colors: 6, Formats: map[string]string{"hypr": "$bg = {{ .Background.Bare }}"}, Outputs: []PaletteOutput{{Format: "hypr", Path: "/tmp/colors.conf"}, {Format: "kitty", Path: "/tmp/kitty.conf"}}}}, false},
+		{"invalid palette colors", &Config{Palette: PaletteConfig{Colors: 100}}, true},
+		{"invalid palette format", &Config{Palette: PaletteConfig{Formats: map[string]string{"broken": "{{ .Background"}}}, true},
+		{"unknown palette format", &Config{Palette: PaletteConfig{Outputs: []PaletteOutput{{Format: "xresources", Path: "/tmp/x"}}}}, true},
+		{"hooks", &Config{Hooks: HooksConfig{PostSet: []string{"pkill -USR2 waybar"}, Timeout: "5s"}}, false},
+		{"invalid hooks timeout", &Config{Hooks: HooksConfig{Timeout: "soon"}}, true},
+		{"negative hooks timeout", &Config{Hooks: HooksConfig{Timeout: "-1s"}}, true},
+		{"schedule", &Config{Schedule: schedule.Schedule{{From: "22:00", To: "06:00", Directories: []string{tempDir}}, {Cron: "* 6-11 * * mon-fri", Tags: []string{"calm"}}}}, false},
+		{"invalid schedule", &Config{Schedule: schedule.Schedule{{From: "22:00", Tags: []string{"dark"}}}}, true},
+		{"missing schedule directory", &Config{Schedule: schedule.Schedule{{Cron: "* * * * *", Directories: []string{nonExistent}}}}, true},
+		{"sun", &Config{Sun: &SunConfig{Location: sun.Location{Latitude: 45.46, Longitude: 9.19}, Night: PoolConfig{Directories: []string{tempDir}}}}, false},
+		{"invalid latitude", &Config{Sun: &SunConfig{Location: sun.Location{Latitude: 95}}}, true},
+		{"missing sun directory", &Config{Sun: &SunConfig{Day: PoolConfig{Directories: []string{nonExistent}}}}, true},
+		{"color scheme", &Config{ColorScheme: &ColorSchemeConfig{Source: "gsettings", Dark: PoolConfig{Directories: []string{tempDir}}}}, false},
+		{"invalid color scheme source", &Config{ColorScheme: &ColorSchemeConfig{Source: "kde"}}, true},
+		{"missing color scheme directory", &Config{ColorScheme: &ColorSchemeConfig{Light: PoolConfig{Directories: []string{nonExistent}}}}, true},
+		{"workspaces", &Config{Workspaces: map[string]string{"1": tempDir}}, false},
+		{"missing workspace wallpaper", &Config{Workspaces: map[string]string{"web": nonExistent}}, true},
+		{"palette output without path", &Config{Palette: PaletteConfig{Outputs: []PaletteOutput{{Format: "json"}}}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -106,8 +149,7 @@ func TestInitConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tempDir := t.TempDir()
-			t.Setenv("HOME", tempDir)
+			tempDir := testutil.Home(t)
 
 			configDir := filepath.Join(tempDir, ".config")
 			if err := os.MkdirAll(configDir, 0o750); err != nil {
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+
+	"github.com/marcosalvi-01/wallman/db"
 
 	"github.com/spf13/cobra"
 )
@@ -9,27 +13,87 @@ import (
 var currentCmd = &cobra.Command{
 	Use:   "current",
 	Short: "Show current wallpaper",
+	Long: `Shows the current wallpaper. In independent monitor mode every monitor is listed with its own wallpaper, use --monitor to only show one.
+--json prints the wallpaper as an object (an array of them when every monitor is listed), --format renders a Go template for every wallpaper and --waybar prints the JSON of a waybar custom module.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		format, _ := cmd.Flags().GetString("format")
+		waybar, _ := cmd.Flags().GetBool("waybar")
 		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
+
+		// Only listing every monitor needs the backend, status bars poll
+		// this.
+		monitors := []string{monitor}
+		all := monitor == "" && config.Independent()
+		if all {
+			managerType := manager
+			if managerType == "" {
+				managerType = config.Manager
+			}
+			backend, err := getBackend(config, managerType)
+			if err != nil {
+				return err
+			}
+			monitors, err = backend.Outputs()
+			if err != nil {
+				return fmt.Errorf("failed to list monitors: %w", err)
+			}
 		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
-			return err
+
+		entries := make([]wallpaperInfo, len(monitors))
+		for i, m := range monitors {
+			current, err := db.GetCurrentWallpaper(m)
+			if err != nil {
+				if all {
+					return fmt.Errorf("monitor %s: %w", m, err)
+				}
+				return err
+			}
+			entries[i] = wallpaperInfo{Path: current.Path, SetAt: current.SetAt, Monitor: m}
 		}
 
-		path, err := man.Current()
+		if !jsonOutput && format == "" && !waybar {
+			for _, entry := range entries {
+				if all {
+					fmt.Printf("%s: %s\n", entry.Monitor, entry.Path)
+				} else {
+					fmt.Println(entry.Path)
+				}
+			}
+			return nil
+		}
+
+		infos, err := newWallpaperInfos(config, entries)
 		if err != nil {
 			return err
 		}
-
-		fmt.Println(path)
-		return nil
+		switch {
+		case waybar:
+			flags, err := db.GetWallpaperFlags()
+			if err != nil {
+				return err
+			}
+			favorites := make(map[string]bool, len(flags))
+			for path, flag := range flags {
+				favorites[path] = flag.Favorite
+			}
+			return printWaybar(os.Stdout, infos, favorites)
+		case format != "":
+			return printFormat(os.Stdout, format, infos)
+		case all:
+			return json.NewEncoder(os.Stdout).Encode(infos)
+		default:
+			return json.NewEncoder(os.Stdout).Encode(infos[0])
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(currentCmd)
+	currentCmd.Flags().String("monitor", "", "Only show the wallpaper of this monitor")
+	currentCmd.Flags().Bool("json", false, "Output in JSON format")
+	currentCmd.Flags().String("format", "", formatUsage)
+	currentCmd.Flags().Bool("waybar", false, "Output the JSON of a waybar custom module")
+	currentCmd.MarkFlagsMutuallyExclusive("json", "format", "waybar")
 }
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/daemon"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/hyprland"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the wallpaper rotation daemon",
+	Long: `Runs in the foreground and changes the wallpaper every daemon.interval using daemon.action (random or next).
+While the daemon is running, the next, previous, forward, random and set commands are sent to it instead of changing the wallpaper themselves.
+The config file is reloaded when it changes, on SIGHUP and with 'wallman daemon reload'.
+With sun or color_scheme, the light and dark variants of the current wallpapers are swapped at sunrise and sunset and when the desktop color scheme changes.
+Under Hyprland, monitors switching to a workspace get its wallpaper, see 'wallman workspace'.
+With daemon.watch or --watch, the library index is kept up to date like 'wallman watch' does; changes to wallpaper_directories need a restart.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		action, _ := cmd.Flags().GetString("action")
+		watch, _ := cmd.Flags().GetBool("watch")
+
+		config := GetConfig()
+		d := &daemon.Daemon{
+			SocketPath: daemon.SocketPath(),
+			ConfigPath: appConfigPath,
+			Reload: func() (daemon.Rotation, error) {
+				loaded, err := reloadConfig()
+				if err != nil {
+					return daemon.Rotation{}, err
+				}
+				config = loaded
+				return rotation(config, interval, action)
+			},
+			Handle: func(req daemon.Request) error {
+				return handleRequest(config, req)
+			},
+		}
+		getConfig := func() *Config { return config }
+		watchers := []watcher{
+			func(ctx context.Context, run func(func() error) error) error {
+				return watchVariant(ctx, getConfig, run)
+			},
+		}
+		if socket, err := hyprland.SocketPath(); err == nil {
+			watchers = append(watchers, func(ctx context.Context, run func(func() error) error) error {
+				focused, err := hyprland.ActiveWorkspace()
+				if err != nil {
+					// Wait for the first event to know the focused workspace.
+					log.Printf("%v", err)
+				}
+				return watchWorkspaces(ctx, socket, focused, getConfig, run)
+			})
+		}
+		if watch || config.Daemon.Watch {
+			watchers = append(watchers, func(ctx context.Context, run func(func() error) error) error {
+				return watchLibrary(ctx, getConfig, run)
+			})
+		}
+		d.Watch = watchAll(watchers)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return d.Run(ctx)
+	},
+}
+
+var daemonReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload the config of the running daemon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return daemon.Send(daemon.SocketPath(), daemon.Request{Action: daemon.ActionReload})
+	},
+}
+
+// watcher runs alongside the daemon, see daemon.Daemon.Watch.
+type watcher func(ctx context.Context, run func(func() error) error) error
+
+// watchAll runs every watcher until ctx is done or one of them fails.
+func watchAll(watchers []watcher) watcher {
+	return func(ctx context.Context, run func(func() error) error) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		errs := make(chan error, len(watchers))
+		for _, w := range watchers {
+			go func() { errs <- w(ctx, run) }()
+		}
+		var first error
+		for range watchers {
+			err := <-errs
+			if err != nil && first == nil && ctx.Err() == nil {
+				first = err
+				cancel()
+			}
+		}
+		return first
+	}
+}
+
+// rotation builds the daemon rotation from config, letting non-zero flag
+// values take precedence.
+func rotation(config *Config, interval time.Duration, action string) (daemon.Rotation, error) {
+	if interval == 0 && config.Daemon.Interval != "" {
+		parsed, err := time.ParseDuration(config.Daemon.Interval)
+		if err != nil {
+			return daemon.Rotation{}, fmt.Errorf("invalid daemon interval: %w", err)
+		}
+		interval = parsed
+	}
+	if interval == 0 {
+		interval = 30 * time.Minute
+	}
+
+	if action == "" {
+		action = config.Daemon.Action
+	}
+	if action == "" {
+		action = daemon.ActionRandom
+	}
+	if action != daemon.ActionRandom && action != daemon.ActionNext {
+		return daemon.Rotation{}, fmt.Errorf("unsupported daemon action: %s", action)
+	}
+
+	return daemon.Rotation{
+		Interval: interval,
+		Request:  daemon.Request{Action: action},
+	}, nil
+}
+
+// run performs req through the running daemon, or locally when there is none.
+func run(req daemon.Request) error {
+	if sent, err := sendToDaemon(req); sent {
+		return err
+	}
+	return handleRequest(GetConfig(), req)
+}
+
+// handleRequest performs req with a manager built from config.
+func handleRequest(config *Config, req daemon.Request) error {
+	config = requestConfig(config, req)
+	managerType := manager
+	if managerType == "" {
+		managerType = config.Manager
+	}
+	if req.Action == daemon.ActionPrevious || req.Action == daemon.ActionForward {
+		if err := checkSteps(req.Steps); err != nil {
+			return err
+		}
+	}
+	man, err := GetManager(config, managerType, appQueries, dryRun)
+	if err != nil {
+		return err
+	}
+
+	switch req.Action {
+	case daemon.ActionNext:
+		err = man.Next(req.Monitor, req.Fit)
+	case daemon.ActionPrevious:
+		err = man.Previous(req.Monitor, req.Steps)
+	case daemon.ActionForward:
+		err = man.Forward(req.Monitor, req.Steps)
+	case daemon.ActionRandom:
+		strategy := req.Strategy
+		if req.TrueRandom {
+			strategy = engine.StrategyUniform
+		}
+		err = man.Random(req.Monitor, strategy, req.Fit)
+	case daemon.ActionSet:
+		err = man.Set(req.Path, req.Monitor, req.Fit)
+	default:
+		return fmt.Errorf("unsupported daemon action: %s", req.Action)
+	}
+	return err
+}
+
+// checkSteps rejects walking the history by fewer than one step.
+func checkSteps(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("invalid count %d (must be at least 1)", steps)
+	}
+	return nil
+}
+
+// requestConfig returns config with the selection settings overridden by req.
+func requestConfig(config *Config, req daemon.Request) *Config {
+	overridden := *config
+	if req.FavoritesOnly {
+		overridden.FavoritesOnly = true
+	}
+	if len(req.Tags) > 0 {
+		overridden.Tags = req.Tags
+	}
+	return &overridden
+}
+
+// sendToDaemon forwards req to a running daemon so that it does not race with
+// this process on the database. It reports false when the request has to be
+// handled locally: no daemon is running, this is a dry run or a specific
+// manager was forced.
+func sendToDaemon(req daemon.Request) (bool, error) {
+	if dryRun || manager != "" {
+		return false, nil
+	}
+
+	if req.Path != "" {
+		// The daemon does not share our working directory.
+		abs, err := filepath.Abs(common.ExpandPath(req.Path))
+		if err != nil {
+			return true, fmt.Errorf("failed to resolve wallpaper path: %w", err)
+		}
+		req.Path = abs
+	}
+
+	err := daemon.Send(daemon.SocketPath(), req)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		return false, nil
+	}
+	return true, err
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonReloadCmd)
+	daemonCmd.Flags().Duration("interval", 0, "Time between two rotations (overrides daemon.interval)")
+	daemonCmd.Flags().String("action", "", "Rotation to perform: random or next (overrides daemon.action)")
+	daemonCmd.Flags().Bool("watch", false, "Keep the library index up to date (same as daemon.watch)")
+}
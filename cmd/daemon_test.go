@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/daemon"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+)
+
+func TestHandleRequestSteps(t *testing.T) {
+	testutil.Home(t)
+
+	for _, action := range []string{daemon.ActionPrevious, daemon.ActionForward} {
+		for _, steps := range []int{0, -3} {
+			err := handleRequest(&Config{}, daemon.Request{Action: action, Steps: steps})
+			if err == nil || !strings.Contains(err.Error(), "invalid count") {
+				t.Errorf("handleRequest(%s, %d steps) = %v, want an invalid count", action, steps, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+
+	"github.com/spf13/cobra"
+)
+
+var favCmd = &cobra.Command{
+	Use:   "fav [path]",
+	Short: "Mark a wallpaper as favorite",
+	Long:  `Marks a wallpaper as favorite, defaulting to the current one. Favorites can be selected exclusively with the --favorites flag of next and random, or with favorites_only in the config. Use --remove to unmark it.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, _ := cmd.Flags().GetBool("remove")
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		paths, err := targetPaths(args, monitor)
+		if err != nil {
+			return err
+		}
+		path := paths[0]
+
+		err = db.SetFavorite(path, !remove)
+		if err != nil {
+			return err
+		}
+
+		if remove {
+			fmt.Printf("Removed %s from favorites\n", path)
+		} else {
+			fmt.Printf("Added %s to favorites\n", path)
+		}
+		return nil
+	},
+}
+
+// targetPaths resolves the wallpapers a fav, ban or tag command applies to: the
+// path arguments if given, the current wallpaper of monitor otherwise.
+func targetPaths(args []string, monitor string) ([]string, error) {
+	if len(args) == 0 {
+		current, err := db.GetCurrentWallpaperPath(monitor)
+		if err != nil {
+			return nil, err
+		}
+		return []string{current}, nil
+	}
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		path, err := filepath.Abs(common.ExpandPath(arg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve wallpaper path: %w", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to access wallpaper file: %w", err)
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
+
+func init() {
+	rootCmd.AddCommand(favCmd)
+	favCmd.Flags().Bool("remove", false, "Remove the wallpaper from favorites")
+	favCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+}
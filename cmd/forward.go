@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/marcosalvi-01/wallman/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward",
+	Short: "Return to a newer wallpaper in history",
+	Long:  `Undoes 'previous' by walking forward through the wallpaper history, up to the most recently set wallpaper. Use -n to go forward several steps and --monitor to only change one monitor.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+		steps, _ := cmd.Flags().GetInt("count")
+		if err := checkSteps(steps); err != nil {
+			return err
+		}
+		return run(daemon.Request{Action: daemon.ActionForward, Monitor: monitor, Steps: steps})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forwardCmd)
+	forwardCmd.Flags().String("monitor", "", "Only change the wallpaper of this monitor")
+	forwardCmd.Flags().IntP("count", "n", 1, "Number of steps to go forward")
+}
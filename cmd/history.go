@@ -5,43 +5,53 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/marcosalvi-01/wallman/db"
+
 	"github.com/spf13/cobra"
 )
 
 var historyCmd = &cobra.Command{
 	Use:   "history",
 	Short: "Show wallpaper history",
+	Long: `Shows the wallpaper history, most recent first. Use --monitor to only show the wallpapers that were shown on one monitor.
+--json prints an array of objects and --format renders a Go template for every entry, like current.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jsonOutput, _ := cmd.Flags().GetBool("json")
-
+		format, _ := cmd.Flags().GetString("format")
+		monitor, _ := cmd.Flags().GetString("monitor")
 		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
-		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
-			return err
-		}
 
-		paths, err := man.History()
+		history, err := db.GetWallpaperHistory(monitor, 100)
 		if err != nil {
 			return err
 		}
 
-		if jsonOutput {
-			return json.NewEncoder(os.Stdout).Encode(paths)
+		if !jsonOutput && format == "" {
+			for _, entry := range history {
+				fmt.Println(entry.Path)
+			}
+			return nil
 		}
 
-		for _, path := range paths {
-			fmt.Println(path)
+		entries := make([]wallpaperInfo, len(history))
+		for i, entry := range history {
+			entries[i] = wallpaperInfo{Path: entry.Path, SetAt: entry.SetAt, Monitor: entry.Monitor}
 		}
-
-		return nil
+		infos, err := newWallpaperInfos(config, entries)
+		if err != nil {
+			return err
+		}
+		if format != "" {
+			return printFormat(os.Stdout, format, infos)
+		}
+		return json.NewEncoder(os.Stdout).Encode(infos)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(historyCmd)
 	historyCmd.Flags().Bool("json", false, "Output in JSON format")
+	historyCmd.Flags().String("format", "", formatUsage)
+	historyCmd.Flags().String("monitor", "", "Only show the history of this monitor")
+	historyCmd.MarkFlagsMutuallyExclusive("json", "format")
 }
@@ -5,30 +5,86 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/library"
 
 	"github.com/spf13/cobra"
 )
 
+// listEntry is a wallpaper in the JSON output of list.
+type listEntry struct {
+	Path     string   `json:"path"`
+	Favorite bool     `json:"favorite"`
+	Banned   bool     `json:"banned"`
+	Rating   int64    `json:"rating,omitempty"`
+	Tags     []string `json:"tags"`
+	Width    int64    `json:"width,omitempty"`
+	Height   int64    `json:"height,omitempty"`
+	Format   string   `json:"format,omitempty"`
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all available wallpapers",
-	Long:  `Lists all wallpapers found in the configured directories.`,
+	Long:  `Lists all wallpapers found in the configured directories, as recorded in the library index. Use --favorites or --banned to only list the favorite or banned ones and --tag to only list wallpapers with the given tags.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jsonOutput, _ := cmd.Flags().GetBool("json")
+		favorites, _ := cmd.Flags().GetBool("favorites")
+		banned, _ := cmd.Flags().GetBool("banned")
+		wantTags, _ := cmd.Flags().GetStringSlice("tag")
 		config := GetConfig()
 
-		walls, err := common.List(config.WallpaperDirs, config.TravelSubDirs)
+		walls, err := library.Load(config.LibraryOptions())
 		if err != nil {
 			return fmt.Errorf("failed to list wallpapers: %w", err)
 		}
+		paths := make([]string, len(walls))
+		for i, wall := range walls {
+			paths[i] = wall.Path
+		}
 
-		if jsonOutput {
-			return json.NewEncoder(os.Stdout).Encode(walls)
+		flags, err := db.GetWallpaperFlags()
+		if err != nil {
+			return err
 		}
 
+		tags, err := engine.Tags(paths, tagOptions(config))
+		if err != nil {
+			return err
+		}
+
+		entries := make([]listEntry, 0, len(walls))
 		for _, wall := range walls {
-			fmt.Println(wall)
+			flag := flags[wall.Path]
+			if (favorites && !flag.Favorite) || (banned && !flag.Banned) {
+				continue
+			}
+			if !engine.HasTags(tags[wall.Path], wantTags) {
+				continue
+			}
+			wallTags := tags[wall.Path]
+			if wallTags == nil {
+				wallTags = []string{}
+			}
+			entries = append(entries, listEntry{
+				Path:     wall.Path,
+				Favorite: flag.Favorite,
+				Banned:   flag.Banned,
+				Rating:   flag.Rating,
+				Tags:     wallTags,
+				Width:    wall.Width,
+				Height:   wall.Height,
+				Format:   wall.Format,
+			})
+		}
+
+		if jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(entries)
+		}
+
+		for _, entry := range entries {
+			fmt.Println(entry.Path)
 		}
 
 		return nil
@@ -38,4 +94,7 @@ var listCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().Bool("json", false, "Output in JSON format")
+	listCmd.Flags().Bool("favorites", false, "Only list favorite wallpapers")
+	listCmd.Flags().Bool("banned", false, "Only list banned wallpapers")
+	listCmd.Flags().StringSlice("tag", nil, "Only list wallpapers with this tag (repeatable)")
 }
@@ -3,22 +3,76 @@ package cmd
 import (
 	"fmt"
 	"runtime"
+	"time"
 
+	"github.com/marcosalvi-01/wallman/command"
+	"github.com/marcosalvi-01/wallman/db"
 	"github.com/marcosalvi-01/wallman/db/sqlc"
+	"github.com/marcosalvi-01/wallman/engine"
 	"github.com/marcosalvi-01/wallman/hyprpaper"
 	"github.com/marcosalvi-01/wallman/macos"
+	"github.com/marcosalvi-01/wallman/swww"
 )
 
+// Manager is what the commands use to change wallpapers. It is implemented by
+// engine.Engine on top of the backend selected in the config. An empty monitor
+// targets all monitors: with the shared monitor mode they all show the same
+// wallpaper, in independent mode each of them gets its own selection.
 type Manager interface {
-	Next() error
-	Previous() error
-	Random(trueRandom bool) error
-	Current() (string, error)
-	History() ([]string, error)
-	Set(path string) error
+	Next(monitor, fit string) error
+	Previous(monitor string, steps int) error
+	Forward(monitor string, steps int) error
+	Random(monitor, strategy, fit string) error
+	CycleStatus() (shown, total int, err error)
+	SyncCycle() error
+	Set(path, monitor, fit string) error
+	Monitors() ([]string, error)
+	ApplyVariant(monitor string) error
 }
 
-func GetManager(wallpaperDirs []string, travelSubdir bool, managerType string, queries *sqlc.Queries, dryRun bool) (Manager, error) {
+func GetManager(config *Config, managerType string, queries *sqlc.Queries, dryRun bool) (Manager, error) {
+	backend, err := getBackend(config, managerType)
+	if err != nil {
+		return nil, err
+	}
+
+	now, scheme := time.Now(), colorScheme(config)
+	pool := activePool(config, now, scheme)
+	if pool.Playlist == "" {
+		// The configured pools win over 'wallman playlist use'.
+		pool.Playlist, err = db.GetActivePlaylist()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return engine.New(backend, engine.Options{
+		WallpaperDirs:    config.WallpaperDirs,
+		TravelSubDirs:    config.TravelSubDirs,
+		Independent:      config.Independent(),
+		Span:             config.Span(),
+		DryRun:           dryRun,
+		FavoritesOnly:    config.FavoritesOnly,
+		Tags:             config.Tags,
+		AutoTags:         config.AutoTags,
+		Strategy:         config.RandomStrategy,
+		RecentDays:       config.RandomRecentDays,
+		ScanInterval:     config.LibraryOptions().MaxAge,
+		Match:            config.Match,
+		Fit:              config.Fit,
+		DirectoryFits:    config.FitDirectories,
+		MonitorFits:      config.FitMonitors,
+		Pipelines:        config.Pipelines,
+		Pipeline:         config.Pipeline,
+		TagPipelines:     config.PipelineTags,
+		MonitorPipelines: config.PipelineMonitors,
+		Hooks:            config.EngineHooks(),
+		OnChange:         exportPalette(config),
+		Pool:             pool,
+		Variant:          activeVariant(config, now, scheme),
+	}, queries)
+}
+
+func getBackend(config *Config, managerType string) (engine.Backend, error) {
 	if managerType == "" || managerType == "auto" {
 		if runtime.GOOS == "darwin" {
 			managerType = "mac"
@@ -28,9 +82,13 @@ func GetManager(wallpaperDirs []string, travelSubdir bool, managerType string, q
 	}
 	switch managerType {
 	case "hyprpaper":
-		return hyprpaper.New(wallpaperDirs, travelSubdir, queries, dryRun)
+		return hyprpaper.New(), nil
+	case "swww":
+		return swww.New(config.Swww), nil
+	case "command":
+		return command.New(config.Command)
 	case "mac":
-		return macos.New(wallpaperDirs, travelSubdir, queries, dryRun)
+		return macos.New(), nil
 	default:
 		return nil, fmt.Errorf("unsupported manager type: %s", managerType)
 	}
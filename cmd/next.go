@@ -1,27 +1,28 @@
 package cmd
 
 import (
+	"github.com/marcosalvi-01/wallman/daemon"
+
 	"github.com/spf13/cobra"
 )
 
 var nextCmd = &cobra.Command{
 	Use:   "next",
 	Short: "Set next wallpaper",
+	Long:  `Sets the next wallpaper in alphabetical order, skipping banned wallpapers. Use --favorites to only go through favorites, --tag to only go through wallpapers with the given tags, --monitor to only change one monitor and --fit to override the configured fit mode.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
-		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
-			return err
-		}
-
-		return man.Next()
+		monitor, _ := cmd.Flags().GetString("monitor")
+		favorites, _ := cmd.Flags().GetBool("favorites")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		fit, _ := cmd.Flags().GetString("fit")
+		return run(daemon.Request{Action: daemon.ActionNext, Monitor: monitor, FavoritesOnly: favorites, Tags: tags, Fit: fit})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(nextCmd)
+	nextCmd.Flags().String("monitor", "", "Only change the wallpaper of this monitor")
+	nextCmd.Flags().Bool("favorites", false, "Only go through favorite wallpapers")
+	nextCmd.Flags().StringSlice("tag", nil, "Only go through wallpapers with this tag (repeatable)")
+	nextCmd.Flags().String("fit", "", fitUsage)
 }
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/engine"
+)
+
+// wallpaperInfo describes a wallpaper shown on a monitor, for the --json and
+// --format outputs of current and history.
+type wallpaperInfo struct {
+	Path     string    `json:"path"`
+	Basename string    `json:"basename"`
+	SetAt    time.Time `json:"set_at"`
+	Monitor  string    `json:"monitor"`
+	Width    int       `json:"width,omitempty"`
+	Height   int       `json:"height,omitempty"`
+	Tags     []string  `json:"tags"`
+}
+
+// formatUsage is the usage of the --format flags.
+const formatUsage = "Go template rendered for every wallpaper, e.g. '{{ .Basename }} {{ .Width }}x{{ .Height }}' (fields: Path, Basename, SetAt, Monitor, Width, Height, Tags)"
+
+// newWallpaperInfos describes the wallpapers of entries, reading their
+// dimensions from the files. Missing files have none.
+func newWallpaperInfos(config *Config, entries []wallpaperInfo) ([]wallpaperInfo, error) {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+	}
+	tags, err := engine.Tags(paths, tagOptions(config))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]wallpaperInfo, len(entries))
+	for i, entry := range entries {
+		entry.Basename = filepath.Base(entry.Path)
+		entry.Tags = tags[entry.Path]
+		if entry.Tags == nil {
+			entry.Tags = []string{}
+		}
+		if image, err := common.Sniff(entry.Path); err == nil {
+			entry.Width, entry.Height = image.Width, image.Height
+		}
+		infos[i] = entry
+	}
+	return infos, nil
+}
+
+// printFormat renders format once per wallpaper, each on its own line.
+func printFormat(w io.Writer, format string, infos []wallpaperInfo) error {
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid format: %w", err)
+	}
+	for _, info := range infos {
+		if err := tmpl.Execute(w, info); err != nil {
+			return fmt.Errorf("failed to render format: %w", err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// waybarOutput is the JSON a waybar custom module with "return-type": "json"
+// reads.
+type waybarOutput struct {
+	Text    string   `json:"text"`
+	Tooltip string   `json:"tooltip"`
+	Class   []string `json:"class"`
+}
+
+// printWaybar prints the current wallpapers for a waybar custom module: their
+// names as the text and their paths as the tooltip. The class is "wallman",
+// plus "favorite" when every wallpaper is a favorite.
+func printWaybar(w io.Writer, infos []wallpaperInfo, favorites map[string]bool) error {
+	output := waybarOutput{Class: []string{"wallman"}}
+	var text, tooltip []string
+	favorite := len(infos) > 0
+	for _, info := range infos {
+		text = append(text, info.Basename)
+		line := info.Path
+		if info.Monitor != "" {
+			line = info.Monitor + ": " + line
+		}
+		tooltip = append(tooltip, line)
+		favorite = favorite && favorites[info.Path]
+	}
+	output.Text = strings.Join(text, " | ")
+	output.Tooltip = strings.Join(tooltip, "\n")
+	if favorite {
+		output.Class = append(output.Class, "favorite")
+	}
+	return json.NewEncoder(w).Encode(output)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrintFormat(t *testing.T) {
+	infos := []wallpaperInfo{
+		{Path: "/walls/a.png", Basename: "a.png", Monitor: "DP-1", Width: 1920, Height: 1080, Tags: []string{"dark", "city"}},
+		{Path: "/walls/b.jpg", Basename: "b.jpg", SetAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Tags: []string{}},
+	}
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"fields", "{{ .Monitor }} {{ .Basename }} {{ .Width }}x{{ .Height }}", "DP-1 a.png 1920x1080\n b.jpg 0x0\n", false},
+		{"tags", "{{ join .Tags \",\" }}", "dark,city\n\n", false},
+		{"time", "{{ .SetAt.Year }}", "1\n2026\n", false},
+		{"invalid template", "{{ .Path", "", true},
+		{"unknown field", "{{ .Size }}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := printFormat(&out, tt.format, infos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("printFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && out.String() != tt.want {
+				t.Errorf("printFormat() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintWaybar(t *testing.T) {
+	tests := []struct {
+		name      string
+		infos     []wallpaperInfo
+		favorites map[string]bool
+		want      string
+	}{
+		{
+			"shared",
+			[]wallpaperInfo{{Path: "/walls/a.png", Basename: "a.png"}},
+			map[string]bool{"/walls/a.png": true},
+			`{"text":"a.png","tooltip":"/walls/a.png","class":["wallman","favorite"]}`,
+		},
+		{
+			"independent",
+			[]wallpaperInfo{{Path: "/walls/a.png", Basename: "a.png", Monitor: "DP-1"}, {Path: "/walls/b.png", Basename: "b.png", Monitor: "eDP-1"}},
+			map[string]bool{"/walls/a.png": true},
+			`{"text":"a.png | b.png","tooltip":"DP-1: /walls/a.png\neDP-1: /walls/b.png","class":["wallman"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printWaybar(&out, tt.infos, tt.favorites); err != nil {
+				t.Fatalf("printWaybar() failed: %v", err)
+			}
+			if got := out.String(); got != tt.want+"\n" {
+				t.Errorf("printWaybar() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
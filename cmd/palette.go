@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marcosalvi-01/wallman/palette"
+
+	"github.com/spf13/cobra"
+)
+
+// PaletteConfig configures the palettes extracted from the wallpapers.
+type PaletteConfig struct {
+	// Colors is the number of dominant colors extracted, 8 if zero.
+	Colors int `yaml:"colors"`
+	// Formats are text/templates rendered with a palette.Theme, by name.
+	// They can replace the built-in formats.
+	Formats map[string]string `yaml:"formats"`
+	// Outputs are written after every wallpaper change.
+	Outputs []PaletteOutput `yaml:"outputs"`
+}
+
+// PaletteOutput is a file the palette of the current wallpaper is written to.
+type PaletteOutput struct {
+	Format string `yaml:"format"`
+	Path   string `yaml:"path"`
+}
+
+var paletteCmd = &cobra.Command{
+	Use:   "palette [path]",
+	Short: "Show the color palette of a wallpaper",
+	Long: `Shows the dominant colors of a wallpaper, defaulting to the current one, with the share of the image they cover. Use --format to render them with a built-in format (json, env, css, kitty, alacritty, foot) or one of palette.formats, and --write to write the palette.outputs of the config.
+Palettes are cached in the database. When palette.outputs are configured they are written after every wallpaper change.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+		format, _ := cmd.Flags().GetString("format")
+		write, _ := cmd.Flags().GetBool("write")
+		config := GetConfig()
+
+		paths, err := targetPaths(args, monitor)
+		if err != nil {
+			return err
+		}
+		path := paths[0]
+
+		if write {
+			return writePalette(config, path)
+		}
+
+		swatches, err := palette.Load(path, config.Palette.Colors)
+		if err != nil {
+			return err
+		}
+		if format == "" {
+			for _, swatch := range swatches {
+				fmt.Printf("%s %5.1f%%\n", swatch.Color.Hex(), swatch.Weight*100)
+			}
+			return nil
+		}
+
+		out, err := palette.Render(format, config.Palette.Formats, palette.NewTheme(path, swatches))
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
+
+// writePalette renders the palette of the wallpaper at path to every output
+// of the config.
+func writePalette(config *Config, path string) error {
+	swatches, err := palette.Load(path, config.Palette.Colors)
+	if err != nil {
+		return err
+	}
+	theme := palette.NewTheme(path, swatches)
+
+	for _, output := range config.Palette.Outputs {
+		out, err := palette.Render(output.Format, config.Palette.Formats, theme)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(output.Path), 0o750)
+		if err != nil {
+			return fmt.Errorf("failed to create palette directory: %w", err)
+		}
+		err = os.WriteFile(output.Path, []byte(out), 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to write palette: %w", err)
+		}
+	}
+	return nil
+}
+
+// exportPalette returns the OnChange of the engine writing the palette
+// outputs of the config for the wallpaper just set, nil without outputs.
+// When every monitor changes at once, the palette is the one of the first.
+func exportPalette(config *Config) func(path, monitor string) error {
+	if len(config.Palette.Outputs) == 0 {
+		return nil
+	}
+
+	// A manager lives for a single command, so only its first change counts.
+	exported := false
+	return func(path, _ string) error {
+		if exported {
+			return nil
+		}
+		exported = true
+
+		err := writePalette(config, path)
+		if errors.Is(err, palette.ErrUnsupported) {
+			fmt.Fprintf(os.Stderr, "Not writing the palette: %v\n", err)
+			return nil
+		}
+		return err
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(paletteCmd)
+	paletteCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+	paletteCmd.Flags().String("format", "", "Render the palette in this format instead of listing the colors")
+	paletteCmd.Flags().Bool("write", false, "Write the palette outputs of the config")
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+
+	"github.com/spf13/cobra"
+)
+
+var playlistCmd = &cobra.Command{
+	Use:   "playlist",
+	Short: "Manage playlists",
+	Long: `Manages named playlists of wallpapers. While a playlist is in use, next, previous and forward walk its items in order and random shuffles them, each playlist remembering where it was left and keeping its own random cycle.
+The playlist of the active schedule rule, color scheme or sun period, when they name one, wins over the one in use.`,
+}
+
+var playlistCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an empty playlist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.TrimSpace(args[0])
+		if name == "" {
+			return fmt.Errorf("playlist name cannot be empty")
+		}
+		err := db.CreatePlaylist(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created playlist %s\n", name)
+		return nil
+	},
+}
+
+var playlistAddCmd = &cobra.Command{
+	Use:   "add <name> [path...]",
+	Short: "Add wallpapers to a playlist",
+	Long:  `Appends the given wallpapers to a playlist, defaulting to the current one. Wallpapers already in the playlist keep their place.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		paths, err := targetPaths(args[1:], monitor)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if _, err := common.Sniff(path); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		err = db.AddToPlaylist(args[0], paths)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Printf("Added %s to %s\n", path, args[0])
+		}
+		return nil
+	},
+}
+
+var playlistRmCmd = &cobra.Command{
+	Use:   "rm <name> [path...]",
+	Short: "Remove wallpapers from a playlist",
+	Long:  `Removes the given wallpapers from a playlist, defaulting to the current one. The wallpapers do not have to exist anymore.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		var paths []string
+		if len(args) == 1 {
+			current, err := db.GetCurrentWallpaperPath(monitor)
+			if err != nil {
+				return err
+			}
+			paths = []string{current}
+		}
+		for _, arg := range args[1:] {
+			path, err := filepath.Abs(common.ExpandPath(arg))
+			if err != nil {
+				return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+			}
+			paths = append(paths, path)
+		}
+
+		err := db.RemoveFromPlaylist(args[0], paths)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Printf("Removed %s from %s\n", path, args[0])
+		}
+		return nil
+	},
+}
+
+var playlistShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show playlists",
+	Long:  `Lists the items of a playlist, marking the one shown last with '>', or every playlist with its number of items, marking the one in use with '*'.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			playlists, err := db.GetPlaylists()
+			if err != nil {
+				return err
+			}
+			for _, playlist := range playlists {
+				items, err := db.GetPlaylistItems(playlist.Name)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s %s\t%d\n", marker(playlist.Active, "*"), playlist.Name, len(items))
+			}
+			return nil
+		}
+
+		playlist, found, err := db.GetPlaylist(args[0])
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("unknown playlist: %s", args[0])
+		}
+		items, err := db.GetPlaylistItems(playlist.Name)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			fmt.Printf("%s %s\n", marker(item.Position == playlist.Position, ">"), item.Path)
+		}
+		return nil
+	},
+}
+
+var playlistUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Play a playlist",
+	Long:  `Makes next, previous, forward and random play a playlist, resuming where it was left. With --none every wallpaper can be picked again.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		none, _ := cmd.Flags().GetBool("none")
+		if none == (len(args) == 1) {
+			return fmt.Errorf("expected either a playlist name or --none")
+		}
+
+		if none {
+			err := db.UsePlaylist("")
+			if err != nil {
+				return err
+			}
+			fmt.Println("Stopped using playlists")
+			return nil
+		}
+
+		err := db.UsePlaylist(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Using playlist %s\n", args[0])
+		return nil
+	},
+}
+
+// marker returns mark when set is true, a space otherwise.
+func marker(set bool, mark string) string {
+	if set {
+		return mark
+	}
+	return " "
+}
+
+func init() {
+	rootCmd.AddCommand(playlistCmd)
+	playlistCmd.AddCommand(playlistCreateCmd, playlistAddCmd, playlistRmCmd, playlistShowCmd, playlistUseCmd)
+	playlistAddCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+	playlistRmCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+	playlistUseCmd.Flags().Bool("none", false, "Stop using playlists")
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/colorscheme"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/sun"
+)
+
+// PoolConfig selects some of the wallpapers, see engine.Pool.
+type PoolConfig struct {
+	Directories []string `yaml:"directories"`
+	Tags        []string `yaml:"tags"`
+	Playlist    string   `yaml:"playlist"`
+}
+
+// SunConfig switches between a day and a night pool at sunrise and sunset,
+// computed offline for the location.
+type SunConfig struct {
+	sun.Location `yaml:",inline"`
+	Day          PoolConfig `yaml:"day"`
+	Night        PoolConfig `yaml:"night"`
+}
+
+// ColorSchemeConfig switches between a light and a dark pool following the
+// color scheme preferred by the desktop.
+type ColorSchemeConfig struct {
+	// Source is where the preference is read from, see colorscheme.Sources.
+	Source string     `yaml:"source"`
+	Light  PoolConfig `yaml:"light"`
+	Dark   PoolConfig `yaml:"dark"`
+}
+
+// colorSchemeProvider returns the provider of a color_scheme source. Tests
+// replace it with a fake.
+var colorSchemeProvider = colorscheme.New
+
+// colorScheme returns the color scheme preferred by the desktop, empty when
+// there is no preference or color_scheme is not configured.
+func colorScheme(config *Config) string {
+	if config.ColorScheme == nil {
+		return ""
+	}
+	provider, err := colorSchemeProvider(config.ColorScheme.Source)
+	if err != nil {
+		log.Printf("ignoring the color scheme: %v", err)
+		return ""
+	}
+	scheme, err := provider.Scheme()
+	if err != nil {
+		log.Printf("ignoring the color scheme: %v", err)
+		return ""
+	}
+	return scheme
+}
+
+// activePool returns the wallpapers next and random draw from at now, scheme
+// being the color scheme preferred by the desktop. The directories and
+// playlist of the active schedule rule win over the ones of the color scheme,
+// which win over the ones of the sun period; the tags of all of them are
+// required.
+func activePool(config *Config, now time.Time, scheme string) engine.Pool {
+	var pools []PoolConfig
+	if i := config.Schedule.Active(now); i >= 0 {
+		rule := config.Schedule[i]
+		pools = append(pools, PoolConfig{Directories: rule.Directories, Tags: rule.Tags, Playlist: rule.Playlist})
+	}
+	if config.ColorScheme != nil {
+		switch scheme {
+		case colorscheme.Light:
+			pools = append(pools, config.ColorScheme.Light)
+		case colorscheme.Dark:
+			pools = append(pools, config.ColorScheme.Dark)
+		}
+	}
+	if config.Sun != nil {
+		if config.Sun.Period(now) == sun.Night {
+			pools = append(pools, config.Sun.Night)
+		} else {
+			pools = append(pools, config.Sun.Day)
+		}
+	}
+
+	var pool engine.Pool
+	for _, p := range pools {
+		if len(pool.Dirs) == 0 {
+			pool.Dirs = p.Directories
+		}
+		if pool.Playlist == "" {
+			pool.Playlist = p.Playlist
+		}
+		pool.Tags = slices.Concat(pool.Tags, p.Tags)
+	}
+	return pool
+}
+
+// activeVariant returns the variant of the wallpapers to show at now: the
+// one of the color scheme preferred by the desktop, or else light during the
+// day and dark at night when a location is configured.
+func activeVariant(config *Config, now time.Time, scheme string) string {
+	if config.ColorScheme != nil {
+		switch scheme {
+		case colorscheme.Light:
+			return engine.VariantLight
+		case colorscheme.Dark:
+			return engine.VariantDark
+		}
+	}
+	if config.Sun == nil {
+		return ""
+	}
+	if config.Sun.Period(now) == sun.Night {
+		return engine.VariantDark
+	}
+	return engine.VariantLight
+}
+
+// variantCheckInterval is how often watchVariant checks whether the variant
+// changed. Polling survives suspends and config reloads, changes of the
+// color scheme are also picked up as they happen.
+const variantCheckInterval = time.Minute
+
+// watchVariant swaps the current wallpapers for their variant of the moment
+// whenever it changes, until ctx is done. getConfig is only called through
+// run.
+func watchVariant(ctx context.Context, getConfig func() *Config, run func(func() error) error) error {
+	ticker := time.NewTicker(variantCheckInterval)
+	defer ticker.Stop()
+
+	var provider colorscheme.Provider
+	err := run(func() error {
+		config := getConfig()
+		if config.ColorScheme == nil {
+			return nil
+		}
+		var err error
+		provider, err = colorSchemeProvider(config.ColorScheme.Source)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	changed := make(chan struct{}, 1)
+	if provider != nil {
+		go func() {
+			err := provider.Watch(ctx, func() {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil {
+				log.Printf("%v", err)
+			}
+		}()
+	}
+
+	last := ""
+	for {
+		err := run(func() error {
+			config := getConfig()
+			variant := activeVariant(config, time.Now(), colorScheme(config))
+			if variant == "" || variant == last {
+				return nil
+			}
+
+			managerType := manager
+			if managerType == "" {
+				managerType = config.Manager
+			}
+			man, err := GetManager(config, managerType, appQueries, dryRun)
+			if err != nil {
+				return err
+			}
+			err = man.ApplyVariant("")
+			if err != nil {
+				return err
+			}
+			last = variant
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to swap the wallpaper variant: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/colorscheme"
+	"github.com/marcosalvi-01/wallman/command"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/schedule"
+	"github.com/marcosalvi-01/wallman/sun"
+)
+
+func TestActivePool(t *testing.T) {
+	// Milan, where the sun rises around 05:35 UTC+2 and sets around 21:15 on
+	// the summer solstice.
+	rome := time.FixedZone("CEST", 2*60*60)
+	milan := &SunConfig{
+		Location: sun.Location{Latitude: 45.46, Longitude: 9.19},
+		Day:      PoolConfig{Directories: []string{"/walls/day"}},
+		Night:    PoolConfig{Directories: []string{"/walls/night"}, Tags: []string{"dark"}},
+	}
+	work := schedule.Schedule{{From: "09:00", To: "17:00", Directories: []string{"/walls/work"}, Tags: []string{"calm"}}}
+
+	dark := &ColorSchemeConfig{Dark: PoolConfig{Directories: []string{"/walls/dark"}}, Light: PoolConfig{Tags: []string{"light"}}}
+
+	tests := []struct {
+		name        string
+		config      *Config
+		now         time.Time
+		scheme      string
+		wantPool    engine.Pool
+		wantVariant string
+	}{
+		{"nothing configured", &Config{}, time.Date(2026, 6, 21, 12, 0, 0, 0, rome), "", engine.Pool{}, ""},
+		{"day", &Config{Sun: milan}, time.Date(2026, 6, 21, 12, 0, 0, 0, rome), "", engine.Pool{Dirs: []string{"/walls/day"}}, engine.VariantLight},
+		{"night", &Config{Sun: milan}, time.Date(2026, 6, 21, 23, 0, 0, 0, rome), "", engine.Pool{Dirs: []string{"/walls/night"}, Tags: []string{"dark"}}, engine.VariantDark},
+		{"schedule directories win", &Config{Sun: milan, Schedule: work}, time.Date(2026, 6, 21, 10, 0, 0, 0, rome), "", engine.Pool{Dirs: []string{"/walls/work"}, Tags: []string{"calm"}}, engine.VariantLight},
+		{"tags add up", &Config{Sun: milan, Schedule: schedule.Schedule{{Cron: "* * * * *", Tags: []string{"calm"}}}}, time.Date(2026, 6, 21, 4, 0, 0, 0, rome), "", engine.Pool{Dirs: []string{"/walls/night"}, Tags: []string{"calm", "dark"}}, engine.VariantDark},
+		{"dark scheme", &Config{ColorScheme: dark}, time.Date(2026, 6, 21, 12, 0, 0, 0, rome), colorscheme.Dark, engine.Pool{Dirs: []string{"/walls/dark"}}, engine.VariantDark},
+		{"color scheme wins over the sun", &Config{ColorScheme: dark, Sun: milan}, time.Date(2026, 6, 21, 23, 0, 0, 0, rome), colorscheme.Light, engine.Pool{Dirs: []string{"/walls/night"}, Tags: []string{"light", "dark"}}, engine.VariantLight},
+		{"no preference", &Config{ColorScheme: dark, Sun: milan}, time.Date(2026, 6, 21, 23, 0, 0, 0, rome), "", engine.Pool{Dirs: []string{"/walls/night"}, Tags: []string{"dark"}}, engine.VariantDark},
+		{"schedule playlist wins", &Config{ColorScheme: &ColorSchemeConfig{Dark: PoolConfig{Playlist: "noir"}}, Schedule: schedule.Schedule{{Cron: "* * * * *", Playlist: "focus"}}}, time.Date(2026, 6, 21, 12, 0, 0, 0, rome), colorscheme.Dark, engine.Pool{Playlist: "focus"}, engine.VariantDark},
+		{"preference without color_scheme", &Config{}, time.Date(2026, 6, 21, 23, 0, 0, 0, rome), colorscheme.Dark, engine.Pool{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := activePool(tt.config, tt.now, tt.scheme); !reflect.DeepEqual(got, tt.wantPool) {
+				t.Errorf("activePool() = %+v, want %+v", got, tt.wantPool)
+			}
+			if got := activeVariant(tt.config, tt.now, tt.scheme); got != tt.wantVariant {
+				t.Errorf("activeVariant() = %q, want %q", got, tt.wantVariant)
+			}
+		})
+	}
+}
+
+func TestWatchVariant(t *testing.T) {
+	home := testutil.Home(t)
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for _, name := range []string{"city-light.png", "city-dark.png"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	if err := db.SetWallpaper(filepath.Join(dir, "city-light.png"), "", ""); err != nil {
+		t.Fatalf("SetWallpaper() failed: %v", err)
+	}
+
+	fake := colorscheme.NewFake(colorscheme.Dark)
+	colorSchemeProvider = func(string) (colorscheme.Provider, error) { return fake, nil }
+	t.Cleanup(func() { colorSchemeProvider = colorscheme.New })
+
+	config := &Config{
+		WallpaperDirs: []string{dir},
+		Manager:       "command",
+		Command:       command.Options{Set: "true {path}"},
+		ColorScheme:   &ColorSchemeConfig{},
+	}
+	var mu sync.Mutex
+	run := func(fn func() error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return fn()
+	}
+	current := func() string {
+		var path string
+		_ = run(func() error {
+			path, _ = db.GetCurrentWallpaperPath("")
+			return nil
+		})
+		return filepath.Base(path)
+	}
+	waitFor := func(want string) {
+		t.Helper()
+		for range 200 {
+			if current() == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("current wallpaper is %s, want %s", current(), want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watchVariant(ctx, func() *Config { return config }, run) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watchVariant() failed: %v", err)
+		}
+	}()
+
+	// The variant is applied on start and then whenever the scheme changes.
+	waitFor("city-dark.png")
+	fake.Set(colorscheme.Light, nil)
+	waitFor("city-light.png")
+}
@@ -1,27 +1,27 @@
 package cmd
 
 import (
+	"github.com/marcosalvi-01/wallman/daemon"
+
 	"github.com/spf13/cobra"
 )
 
 var previousCmd = &cobra.Command{
 	Use:   "previous",
 	Short: "Set previous wallpaper",
+	Long:  `Goes back in the wallpaper history without adding to it, so 'forward' can return to where you were. Use -n to go back several steps and --monitor to only go back on one monitor.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
-		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
+		monitor, _ := cmd.Flags().GetString("monitor")
+		steps, _ := cmd.Flags().GetInt("count")
+		if err := checkSteps(steps); err != nil {
 			return err
 		}
-
-		return man.Previous()
+		return run(daemon.Request{Action: daemon.ActionPrevious, Monitor: monitor, Steps: steps})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(previousCmd)
+	previousCmd.Flags().String("monitor", "", "Only change the wallpaper of this monitor")
+	previousCmd.Flags().IntP("count", "n", 1, "Number of steps to go back")
 }
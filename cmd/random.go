@@ -1,30 +1,77 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/marcosalvi-01/wallman/daemon"
+
 	"github.com/spf13/cobra"
 )
 
 var randomCmd = &cobra.Command{
 	Use:   "random",
 	Short: "Set random wallpaper",
-	Long:  `Sets a random wallpaper. By default, cycles through all wallpapers without repeating until all have been used, then reshuffles. Use --true-random for completely random selection from all wallpapers.`,
+	Long: `Sets a random wallpaper. By default, cycles through all wallpapers without repeating until all have been used, then reshuffles. Banned wallpapers are never picked, use --favorites to only pick favorites and --tag to only pick wallpapers with the given tags. Use --monitor to only change one monitor, --fit to override the configured fit mode and --status to see how far through the cycle you are.
+--strategy (or random_strategy in the config) chooses how the wallpaper is picked:
+  cycle         shuffled cycle through all wallpapers (default)
+  uniform       completely random, same as --true-random
+  weighted      favorites are three times as likely, rated wallpapers as likely as their stars (see rate)
+  least-recent  the wallpaper shown the longest ago, or never shown
+  avoid-recent  skips wallpapers shown in the last random_recent_days (default 7), the oldest if all were`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		trueRandom, _ := cmd.Flags().GetBool("true-random")
-		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
-		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
-			return err
+		if status, _ := cmd.Flags().GetBool("status"); status {
+			return printCycleStatus(cmd)
 		}
 
-		return man.Random(trueRandom)
+		trueRandom, _ := cmd.Flags().GetBool("true-random")
+		strategy, _ := cmd.Flags().GetString("strategy")
+		monitor, _ := cmd.Flags().GetString("monitor")
+		favorites, _ := cmd.Flags().GetBool("favorites")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		fit, _ := cmd.Flags().GetString("fit")
+		return run(daemon.Request{
+			Action:        daemon.ActionRandom,
+			Monitor:       monitor,
+			TrueRandom:    trueRandom,
+			Strategy:      strategy,
+			FavoritesOnly: favorites,
+			Tags:          tags,
+			Fit:           fit,
+		})
 	},
 }
 
+// printCycleStatus reports the progress of the random cycle.
+func printCycleStatus(cmd *cobra.Command) error {
+	favorites, _ := cmd.Flags().GetBool("favorites")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+
+	config := requestConfig(GetConfig(), daemon.Request{FavoritesOnly: favorites, Tags: tags})
+	managerType := manager
+	if managerType == "" {
+		managerType = config.Manager
+	}
+	man, err := GetManager(config, managerType, appQueries, dryRun)
+	if err != nil {
+		return err
+	}
+
+	shown, total, err := man.CycleStatus()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d of %d wallpapers shown, %d left in the cycle\n", shown, total, total-shown)
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(randomCmd)
+	randomCmd.Flags().String("monitor", "", "Only change the wallpaper of this monitor")
+	randomCmd.Flags().Bool("favorites", false, "Only pick favorite wallpapers")
+	randomCmd.Flags().StringSlice("tag", nil, "Only pick wallpapers with this tag (repeatable)")
 	randomCmd.Flags().Bool("true-random", false, "Pick completely random wallpaper from all available (disables cycling)")
+	randomCmd.Flags().String("strategy", "", "Random strategy: cycle, uniform, weighted, least-recent or avoid-recent (overrides random_strategy)")
+	randomCmd.Flags().String("fit", "", fitUsage)
+	randomCmd.Flags().Bool("status", false, "Show the progress of the random cycle instead of changing the wallpaper")
+	randomCmd.MarkFlagsMutuallyExclusive("true-random", "strategy")
 }
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+
+	"github.com/spf13/cobra"
+)
+
+var rateCmd = &cobra.Command{
+	Use:   "rate <stars> [path]",
+	Short: "Rate a wallpaper",
+	Long:  `Rates a wallpaper from 1 to 5 stars, defaulting to the current one. The weighted random strategy picks highly rated wallpapers more often, unrated ones count as 3 stars. Use 0 to remove the rating.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		rating, err := strconv.Atoi(args[0])
+		if err != nil || rating < 0 || rating > engine.MaxRating {
+			return fmt.Errorf("invalid rating %q (expected 0 to %d)", args[0], engine.MaxRating)
+		}
+		paths, err := targetPaths(args[1:], monitor)
+		if err != nil {
+			return err
+		}
+		path := paths[0]
+
+		err = db.SetRating(path, rating)
+		if err != nil {
+			return err
+		}
+
+		if rating == 0 {
+			fmt.Printf("Removed the rating of %s\n", path)
+		} else {
+			fmt.Printf("Rated %s %d/%d\n", path, rating, engine.MaxRating)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rateCmd)
+	rateCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"runtime/debug"
 
+	"github.com/marcosalvi-01/wallman/db"
+
 	"github.com/spf13/cobra"
 )
 
@@ -44,6 +47,9 @@ var rootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if closeErr := db.Close(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to close the database: %v\n", closeErr)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marcosalvi-01/wallman/library"
+
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Update the library index",
+	Long:  `Scans the configured directories and updates the library index that next, random and list read. Only new and modified files are read unless --full is given. Commands also rescan the directories by themselves once the index is older than scan_interval.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		full, _ := cmd.Flags().GetBool("full")
+
+		result, err := library.Scan(GetConfig().LibraryOptions(), full)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Indexed %d wallpapers: %d added, %d updated, %d removed\n", result.Total, result.Added, result.Updated, result.Removed)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.Flags().Bool("full", false, "Read every file again, even if it did not change")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/schedule"
+
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Inspect the wallpaper schedule",
+	Long:  `Inspects the schedule of the config. While a schedule rule is active, next and random only pick wallpapers from its directories and tags.`,
+}
+
+var scheduleShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the active and the next schedule rule",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := GetConfig().Schedule
+		now := time.Now()
+
+		active := s.Active(now)
+		fmt.Printf("now:  %s\n", describeRule(s, active))
+		next, at, ok := s.Next(now)
+		if !ok {
+			fmt.Println("next: no change within a week")
+			return nil
+		}
+		fmt.Printf("next: %s at %s\n", describeRule(s, next), at.Format("Mon Jan 2 15:04"))
+		return nil
+	},
+}
+
+// describeRule describes the i-th rule of s and its pool.
+func describeRule(s schedule.Schedule, i int) string {
+	if i < 0 {
+		return "no rule, every wallpaper can be picked"
+	}
+
+	rule := s[i]
+	when := "cron " + rule.Cron
+	if rule.Cron == "" {
+		when = rule.From + "-" + rule.To
+		if len(rule.Days) > 0 {
+			when += " on " + strings.Join(rule.Days, ",")
+		}
+	}
+	var pool []string
+	if len(rule.Directories) > 0 {
+		pool = append(pool, "directories "+strings.Join(rule.Directories, ", "))
+	}
+	if len(rule.Tags) > 0 {
+		pool = append(pool, "tags "+strings.Join(rule.Tags, ", "))
+	}
+	if rule.Playlist != "" {
+		pool = append(pool, "playlist "+rule.Playlist)
+	}
+	return fmt.Sprintf("%s (%s): %s", s.Label(i), when, strings.Join(pool, "; "))
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleShowCmd)
+}
@@ -1,29 +1,25 @@
 package cmd
 
 import (
+	"github.com/marcosalvi-01/wallman/daemon"
+
 	"github.com/spf13/cobra"
 )
 
 var setCmd = &cobra.Command{
 	Use:   "set <path>",
 	Short: "Set specific wallpaper",
-	Long:  `Sets a specific wallpaper file as active, even if it's not in the configured directories. The path must be to a valid JPEG or PNG file.`,
+	Long:  `Sets a specific wallpaper file as active, even if it's not in the configured directories. The path must be to a valid JPEG, PNG, BMP or WEBP file. Use --monitor to only change one monitor and --fit to override the configured fit mode.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		config := GetConfig()
-		managerType := manager
-		if managerType == "" {
-			managerType = config.Manager
-		}
-		man, err := GetManager(config.WallpaperDirs, config.TravelSubDirs, managerType, appQueries, dryRun)
-		if err != nil {
-			return err
-		}
-
-		return man.Set(args[0])
+		monitor, _ := cmd.Flags().GetString("monitor")
+		fit, _ := cmd.Flags().GetString("fit")
+		return run(daemon.Request{Action: daemon.ActionSet, Monitor: monitor, Path: args[0], Fit: fit})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(setCmd)
+	setCmd.Flags().String("monitor", "", "Only change the wallpaper of this monitor")
+	setCmd.Flags().String("fit", "", fitUsage)
 }
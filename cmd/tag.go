@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/library"
+
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage wallpaper tags",
+	Long:  `Manages wallpaper tags. The --tag flag of next, random and list only considers wallpapers having all the given tags. With auto_tags and travel_sub_directories on, wallpapers are also tagged with the names of their subdirectories.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <tag> [path...]",
+	Short: "Tag wallpapers",
+	Long:  `Adds a tag to the given wallpapers, defaulting to the current one.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editTag(cmd, args, db.AddTag, "Tagged %s with %s\n")
+	},
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:   "rm <tag> [path...]",
+	Short: "Untag wallpapers",
+	Long:  `Removes a tag from the given wallpapers, defaulting to the current one. Tags derived from subdirectories cannot be removed.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editTag(cmd, args, db.RemoveTag, "Removed %[2]s from %[1]s\n")
+	},
+}
+
+var tagLsCmd = &cobra.Command{
+	Use:   "ls [path]",
+	Short: "List tags",
+	Long:  `Lists the tags of a wallpaper, or every tag with the number of wallpapers in the configured directories having it.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := GetConfig()
+
+		if len(args) == 1 {
+			paths, err := targetPaths(args, "")
+			if err != nil {
+				return err
+			}
+			tags, err := engine.Tags(paths, tagOptions(config))
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags[paths[0]] {
+				fmt.Println(tag)
+			}
+			return nil
+		}
+
+		walls, err := library.List(config.LibraryOptions())
+		if err != nil {
+			return fmt.Errorf("failed to list wallpapers: %w", err)
+		}
+
+		tags, err := engine.Tags(walls, tagOptions(config))
+		if err != nil {
+			return err
+		}
+
+		counts := make(map[string]int)
+		for _, wallTags := range tags {
+			for _, tag := range wallTags {
+				counts[tag]++
+			}
+		}
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Printf("%s\t%d\n", name, counts[name])
+		}
+		return nil
+	},
+}
+
+// editTag applies edit to the tag in args[0] and every wallpaper in the rest
+// of args, or the current wallpaper when there are none.
+func editTag(cmd *cobra.Command, args []string, edit func(path, tag string) error, message string) error {
+	monitor, _ := cmd.Flags().GetString("monitor")
+
+	tag := strings.TrimSpace(args[0])
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	paths, err := targetPaths(args[1:], monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		err = edit(path, tag)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(message, path, tag)
+	}
+	return nil
+}
+
+// tagOptions returns the engine options that decide which tags a wallpaper has.
+func tagOptions(config *Config) engine.Options {
+	return engine.Options{
+		WallpaperDirs: config.WallpaperDirs,
+		TravelSubDirs: config.TravelSubDirs,
+		AutoTags:      config.AutoTags,
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd, tagRmCmd, tagLsCmd)
+	tagAddCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+	tagRmCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/library"
+
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep the library index up to date",
+	Long: `Watches the configured directories and updates the library index as soon as images are added, renamed or deleted (Linux only).
+Renamed wallpapers keep their history, flags and tags, while deleted ones are dropped from the history and the current wallpapers. The random cycle follows both right away.
+The daemon does the same when daemon.watch is set or with 'wallman daemon --watch'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return watchLibrary(ctx, GetConfig, nil)
+	},
+}
+
+// watchLibrary brings the library index up to date and then keeps it so
+// until ctx is done. getConfig is called for every change so that config
+// reloads are picked up; run is passed on to library.Watch.
+func watchLibrary(ctx context.Context, getConfig func() *Config, run func(func() error) error) error {
+	options := getConfig().LibraryOptions()
+	if run == nil {
+		run = func(fn func() error) error { return fn() }
+	}
+
+	err := run(func() error {
+		_, err := library.Scan(options, false)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("watching %d wallpaper directories", len(options.Dirs))
+	return library.Watch(ctx, options, run, func(change library.Change) error {
+		log.Printf("library changed: %d added, %d updated, %d removed, %d renamed",
+			change.Result.Added, change.Result.Updated, len(change.Removed), len(change.Renamed))
+		return syncLibrary(getConfig(), change)
+	})
+}
+
+// syncLibrary updates the stored state after the library changed.
+func syncLibrary(config *Config, change library.Change) error {
+	err := engine.Sync(change)
+	if err != nil {
+		return err
+	}
+
+	managerType := manager
+	if managerType == "" {
+		managerType = config.Manager
+	}
+	man, err := GetManager(config, managerType, appQueries, dryRun)
+	if err != nil {
+		return err
+	}
+	return man.SyncCycle()
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
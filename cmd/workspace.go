@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/daemon"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/hyprland"
+
+	"github.com/spf13/cobra"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage Hyprland workspace wallpapers",
+	Long: `Manages the wallpapers of Hyprland workspaces. While the daemon runs under Hyprland, it shows the wallpaper of a workspace whenever a monitor switches to it; workspaces without one keep the wallpaper they find.
+Workspaces are named as Hyprland names them, e.g. "1" or "web". The wallpapers set here win over the workspaces of the config.`,
+}
+
+var workspaceSetCmd = &cobra.Command{
+	Use:   "set <workspace> [path]",
+	Short: "Set the wallpaper of a workspace",
+	Long:  `Maps a workspace to a wallpaper, defaulting to the current one.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitor, _ := cmd.Flags().GetString("monitor")
+
+		workspace := strings.TrimSpace(args[0])
+		if workspace == "" {
+			return fmt.Errorf("workspace cannot be empty")
+		}
+		paths, err := targetPaths(args[1:], monitor)
+		if err != nil {
+			return err
+		}
+		if _, err := common.Sniff(paths[0]); err != nil {
+			return fmt.Errorf("%s: %w", paths[0], err)
+		}
+
+		err = db.SetWorkspaceWallpaper(workspace, paths[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Workspace %s shows %s\n", workspace, paths[0])
+		return nil
+	},
+}
+
+var workspaceRmCmd = &cobra.Command{
+	Use:   "rm <workspace>",
+	Short: "Remove the wallpaper of a workspace",
+	Long:  `Removes the wallpaper set for a workspace with 'wallman workspace set'. The one of the config, if any, applies again.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := db.RemoveWorkspaceWallpaper(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed the wallpaper of workspace %s\n", args[0])
+		return nil
+	},
+}
+
+var workspaceLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List workspace wallpapers",
+	Long:  `Lists the workspaces having a wallpaper, from the database or the config.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallpapers, err := workspaceWallpapers(GetConfig())
+		if err != nil {
+			return err
+		}
+		for _, workspace := range slices.Sorted(maps.Keys(wallpapers)) {
+			fmt.Printf("%s\t%s\n", workspace, wallpapers[workspace])
+		}
+		return nil
+	},
+}
+
+// workspaceWallpapers returns the wallpaper of every workspace having one,
+// the ones of the database winning over the ones of config.
+func workspaceWallpapers(config *Config) (map[string]string, error) {
+	stored, err := db.GetWorkspaceWallpapers()
+	if err != nil {
+		return nil, err
+	}
+	wallpapers := maps.Clone(config.Workspaces)
+	if wallpapers == nil {
+		wallpapers = make(map[string]string, len(stored))
+	}
+	maps.Copy(wallpapers, stored)
+	return wallpapers, nil
+}
+
+// workspaceWallpaper returns the wallpaper of workspace, reporting whether it
+// has one.
+func workspaceWallpaper(config *Config, workspace string) (string, bool, error) {
+	path, found, err := db.GetWorkspaceWallpaper(workspace)
+	if err != nil || found {
+		return path, found, err
+	}
+	path, found = config.Workspaces[workspace]
+	return path, found, nil
+}
+
+// watchWorkspaces listens to the Hyprland event socket and shows the
+// wallpaper of every workspace a monitor switches to, starting with the
+// focused one, until ctx is done. getConfig is only called through run.
+// Losing the socket only stops following the workspaces.
+func watchWorkspaces(ctx context.Context, socket string, focused hyprland.Focus, getConfig func() *Config, run func(func() error) error) error {
+	show := func(focus hyprland.Focus) {
+		err := run(func() error {
+			config := getConfig()
+			path, found, err := workspaceWallpaper(config, focus.Workspace)
+			if err != nil || !found {
+				return err
+			}
+			if current, err := db.GetCurrentWallpaperPath(focus.Monitor); err == nil && current == path {
+				return nil
+			}
+			return handleRequest(config, daemon.Request{Action: daemon.ActionSet, Path: path, Monitor: focus.Monitor})
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to set the wallpaper of workspace %s: %v", focus.Workspace, err)
+		}
+	}
+
+	if focused.Workspace != "" {
+		show(focused)
+	}
+	tracker := hyprland.NewTracker(focused)
+	err := hyprland.Listen(ctx, socket, func(event hyprland.Event) {
+		if focus, ok := tracker.Update(event); ok {
+			show(focus)
+		}
+	})
+	if err != nil {
+		log.Printf("no longer following the Hyprland workspaces: %v", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceSetCmd, workspaceRmCmd, workspaceLsCmd)
+	workspaceSetCmd.Flags().String("monitor", "", "Use the current wallpaper of this monitor")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/command"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/hyprland"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+)
+
+func TestWatchWorkspaces(t *testing.T) {
+	home := testutil.Home(t)
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for _, name := range []string{"code.png", "web.png", "chat.png", "other.png"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	// The database wins over the config.
+	if err := db.SetWorkspaceWallpaper("2", filepath.Join(dir, "web.png")); err != nil {
+		t.Fatalf("SetWorkspaceWallpaper() failed: %v", err)
+	}
+
+	config := &Config{
+		WallpaperDirs: []string{dir},
+		Manager:       "command",
+		Command:       command.Options{Set: "true {path}", Monitors: "printf 'DP-1\\nHDMI-A-1\\n'"},
+		Workspaces: map[string]string{
+			"1": filepath.Join(dir, "code.png"),
+			"2": filepath.Join(dir, "other.png"),
+			"4": filepath.Join(dir, "chat.png"),
+		},
+	}
+
+	// Workspace 2 on DP-1, then the focus moves to HDMI-A-1 showing the
+	// unmapped workspace 3, which switches to workspace 4.
+	socket := filepath.Join(t.TempDir(), ".socket2.sock")
+	replay, err := hyprland.NewReplay(socket, []string{
+		"workspace>>2",
+		"workspacev2>>2,2",
+		"focusedmon>>HDMI-A-1,3",
+		"activewindow>>,",
+		"workspace>>4",
+	})
+	if err != nil {
+		t.Fatalf("NewReplay() failed: %v", err)
+	}
+	defer replay.Close()
+
+	var mu sync.Mutex
+	run := func(fn func() error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return fn()
+	}
+	current := func(monitor string) string {
+		var path string
+		_ = run(func() error {
+			path, _ = db.GetCurrentWallpaperPath(monitor)
+			return nil
+		})
+		return filepath.Base(path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watchWorkspaces(ctx, socket, hyprland.Focus{Monitor: "DP-1", Workspace: "1"}, func() *Config { return config }, run)
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watchWorkspaces() failed: %v", err)
+		}
+	}()
+
+	for range 200 {
+		if current("DP-1") == "web.png" && current("HDMI-A-1") == "chat.png" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := current("DP-1"); got != "web.png" {
+		t.Errorf("DP-1 shows %s, want web.png", got)
+	}
+	if got := current("HDMI-A-1"); got != "chat.png" {
+		t.Errorf("HDMI-A-1 shows %s, want chat.png", got)
+	}
+
+	history, err := db.GetWallpaperHistory("", 10)
+	if err != nil {
+		t.Fatalf("GetWallpaperHistory() failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Errorf("history has %d entries, want code.png, web.png and chat.png", len(history))
+	}
+}
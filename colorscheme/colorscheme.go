@@ -0,0 +1,184 @@
+// Package colorscheme reads the light or dark preference of the desktop, from
+// the org.freedesktop.appearance color-scheme setting of the XDG desktop
+// portal or from the color-scheme key of GNOME's gsettings.
+package colorscheme
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Schemes reported by a Provider. An empty scheme means no preference.
+const (
+	Light = "light"
+	Dark  = "dark"
+)
+
+// Sources of the preference.
+const (
+	SourceAuto      = "auto"
+	SourcePortal    = "portal"
+	SourceGSettings = "gsettings"
+)
+
+// Sources are the valid sources of New.
+var Sources = []string{SourceAuto, SourcePortal, SourceGSettings}
+
+// Provider reads the color scheme preference of the desktop.
+type Provider interface {
+	// Scheme returns Light, Dark or "" when there is no preference.
+	Scheme() (string, error)
+	// Watch calls changed whenever the preference may have changed, until
+	// ctx is done.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// New returns the provider of source. SourceAuto, or an empty source, uses
+// the portal and falls back to gsettings when the portal is not available.
+func New(source string) (Provider, error) {
+	switch source {
+	case "", SourceAuto:
+		return auto{}, nil
+	case SourcePortal:
+		return Portal{}, nil
+	case SourceGSettings:
+		return GSettings{}, nil
+	default:
+		return nil, fmt.Errorf("unknown color scheme source %q (expected one of %s)", source, strings.Join(Sources, ", "))
+	}
+}
+
+const (
+	portalDest      = "org.freedesktop.portal.Desktop"
+	portalPath      = "/org/freedesktop/portal/desktop"
+	portalNamespace = "org.freedesktop.appearance"
+	portalKey       = "color-scheme"
+)
+
+// Portal reads the preference from the XDG desktop portal with gdbus.
+type Portal struct{}
+
+func (Portal) Scheme() (string, error) {
+	args := []string{"call", "--session", "--dest", portalDest, "--object-path", portalPath}
+	out, err := exec.Command("gdbus", append(args, "--method", "org.freedesktop.portal.Settings.ReadOne", portalNamespace, portalKey)...).Output()
+	if err != nil {
+		// Portals older than version 2 only have Read.
+		out, err = exec.Command("gdbus", append(args, "--method", "org.freedesktop.portal.Settings.Read", portalNamespace, portalKey)...).Output()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the portal color scheme: %w", err)
+	}
+	return parsePortal(string(out))
+}
+
+func (Portal) Watch(ctx context.Context, changed func()) error {
+	return watchLines(ctx, changed, func(line string) bool {
+		return strings.Contains(line, "SettingChanged") && strings.Contains(line, portalKey)
+	}, "gdbus", "monitor", "--session", "--dest", portalDest, "--object-path", portalPath)
+}
+
+// parsePortal parses the output of gdbus call, e.g. "(<uint32 1>,)" or
+// "(<<uint32 1>>,)". The portal uses 0 for no preference, 1 for dark and 2
+// for light.
+func parsePortal(out string) (string, error) {
+	value := strings.Trim(strings.TrimSpace(out), "()<>,")
+	value = strings.TrimPrefix(strings.Trim(value, "<>"), "uint32 ")
+	switch value {
+	case "0":
+		return "", nil
+	case "1":
+		return Dark, nil
+	case "2":
+		return Light, nil
+	default:
+		return "", fmt.Errorf("unexpected portal color scheme %q", strings.TrimSpace(out))
+	}
+}
+
+const (
+	gsettingsSchema = "org.gnome.desktop.interface"
+	gsettingsKey    = "color-scheme"
+)
+
+// GSettings reads the preference from GNOME's gsettings.
+type GSettings struct{}
+
+func (GSettings) Scheme() (string, error) {
+	out, err := exec.Command("gsettings", "get", gsettingsSchema, gsettingsKey).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the gsettings color scheme: %w", err)
+	}
+	return parseGSettings(string(out))
+}
+
+func (GSettings) Watch(ctx context.Context, changed func()) error {
+	return watchLines(ctx, changed, func(string) bool { return true }, "gsettings", "monitor", gsettingsSchema, gsettingsKey)
+}
+
+// parseGSettings parses the output of gsettings get, e.g. "'prefer-dark'".
+func parseGSettings(out string) (string, error) {
+	switch value := strings.Trim(strings.TrimSpace(out), "'"); value {
+	case "default":
+		return "", nil
+	case "prefer-dark":
+		return Dark, nil
+	case "prefer-light":
+		return Light, nil
+	default:
+		return "", fmt.Errorf("unexpected gsettings color scheme %q", value)
+	}
+}
+
+// auto prefers the portal and falls back to gsettings.
+type auto struct{}
+
+func (auto) Scheme() (string, error) {
+	scheme, err := Portal{}.Scheme()
+	if err == nil {
+		return scheme, nil
+	}
+	scheme, gsettingsErr := GSettings{}.Scheme()
+	if gsettingsErr != nil {
+		return "", errors.Join(err, gsettingsErr)
+	}
+	return scheme, nil
+}
+
+func (auto) Watch(ctx context.Context, changed func()) error {
+	if _, err := (Portal{}).Scheme(); err == nil {
+		return Portal{}.Watch(ctx, changed)
+	}
+	return GSettings{}.Watch(ctx, changed)
+}
+
+// watchLines runs a monitoring command until ctx is done, calling changed
+// for every line of its output accepted by match.
+func watchLines(ctx context.Context, changed func(), match func(string) bool, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to watch the color scheme: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to watch the color scheme: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if match(scanner.Text()) {
+			changed()
+		}
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("monitor exited")
+	}
+	return fmt.Errorf("stopped watching the color scheme: %w", err)
+}
@@ -0,0 +1,86 @@
+package colorscheme
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePortal(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    string
+		wantErr bool
+	}{
+		{"(<uint32 1>,)\n", Dark, false},
+		{"(<<uint32 2>>,)\n", Light, false},
+		{"(<uint32 0>,)\n", "", false},
+		{"(<uint32 7>,)\n", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parsePortal(tt.out)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePortal(%q) = %q, %v, want %q (error %v)", tt.out, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseGSettings(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    string
+		wantErr bool
+	}{
+		{"'prefer-dark'\n", Dark, false},
+		{"'prefer-light'\n", Light, false},
+		{"'default'\n", "", false},
+		{"'purple'\n", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseGSettings(tt.out)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseGSettings(%q) = %q, %v, want %q (error %v)", tt.out, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, source := range append(Sources, "") {
+		if _, err := New(source); err != nil {
+			t.Errorf("New(%q) failed: %v", source, err)
+		}
+	}
+	if _, err := New("kde"); err == nil {
+		t.Error("New() accepted an unknown source")
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake(Light)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan string, 1)
+	go fake.Watch(ctx, func() {
+		scheme, _ := fake.Scheme()
+		changed <- scheme
+	})
+
+	fake.Set(Dark, nil)
+	select {
+	case scheme := <-changed:
+		if scheme != Dark {
+			t.Errorf("Scheme() = %q after a change, want %q", scheme, Dark)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch() did not report the change")
+	}
+
+	broken := errors.New("no portal")
+	fake.Set("", broken)
+	if _, err := fake.Scheme(); !errors.Is(err, broken) {
+		t.Errorf("Scheme() error = %v, want %v", err, broken)
+	}
+}
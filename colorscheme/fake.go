@@ -0,0 +1,49 @@
+package colorscheme
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake is a Provider whose preference is set by hand, for tests.
+type Fake struct {
+	mu      sync.Mutex
+	scheme  string
+	err     error
+	changed chan struct{}
+}
+
+// NewFake returns a Fake preferring scheme.
+func NewFake(scheme string) *Fake {
+	return &Fake{scheme: scheme, changed: make(chan struct{}, 1)}
+}
+
+// Set changes the preference, failing Scheme when err is not nil, and wakes
+// up Watch.
+func (f *Fake) Set(scheme string, err error) {
+	f.mu.Lock()
+	f.scheme, f.err = scheme, err
+	f.mu.Unlock()
+
+	select {
+	case f.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Fake) Scheme() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.scheme, f.err
+}
+
+func (f *Fake) Watch(ctx context.Context, changed func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-f.changed:
+			changed()
+		}
+	}
+}
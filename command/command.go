@@ -0,0 +1,121 @@
+// Package command provides wallpaper management through user-defined shell
+// command templates, so any wallpaper setter can be used without code changes.
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Options are the command templates exposed in the wallman config.
+type Options struct {
+	// Set is the shell command that applies a wallpaper, e.g.
+	// "swaybg -o {monitor} -i {path} -m fill". The placeholders {path},
+	// {monitor} and {fit} are replaced by shell-quoted values.
+	Set string `yaml:"set,omitempty"`
+	// Monitors is an optional shell command printing one output name per line.
+	// It is required for per-monitor wallpapers unless --monitor is given.
+	Monitors string `yaml:"monitors,omitempty"`
+}
+
+// Command is an engine.Backend running the configured shell commands.
+type Command struct {
+	options Options
+}
+
+func New(options Options) (*Command, error) {
+	if options.Set == "" {
+		return nil, fmt.Errorf("command manager requires command.set in the config")
+	}
+	return &Command{options: options}, nil
+}
+
+func (c *Command) Apply(path, fit string, outputs []string) error {
+	lines, err := c.lines(path, fit, outputs)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		out, err := exec.Command("sh", "-c", line).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("command %q failed: %w: %s", line, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// Preview prints the expanded command lines instead of running them.
+func (c *Command) Preview(path, fit string, outputs []string) error {
+	lines, err := c.lines(path, fit, outputs)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// lines expands the set command for every output. A template without
+// {monitor} is run once and is expected to cover all outputs.
+func (c *Command) lines(path, fit string, outputs []string) ([]string, error) {
+	if !usesMonitor(c.options.Set) {
+		if len(outputs) > 0 {
+			return nil, fmt.Errorf("command.set has no {monitor} placeholder, cannot target single monitors")
+		}
+		return []string{expand(c.options.Set, path, "", fit)}, nil
+	}
+
+	if len(outputs) == 0 {
+		listed, err := c.Outputs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list monitors: %w", err)
+		}
+		outputs = listed
+	}
+
+	lines := make([]string, len(outputs))
+	for i, output := range outputs {
+		lines[i] = expand(c.options.Set, path, output, fit)
+	}
+	return lines, nil
+}
+
+func (c *Command) Outputs() ([]string, error) {
+	if c.options.Monitors == "" {
+		return nil, fmt.Errorf("command.monitors is not configured")
+	}
+	out, err := exec.Command("sh", "-c", c.options.Monitors).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run monitors command: %w", err)
+	}
+	return parseMonitors(string(out)), nil
+}
+
+// expand fills the placeholders of a command template with shell-quoted values.
+func expand(template, path, monitor, fit string) string {
+	return strings.NewReplacer(
+		"{path}", shellQuote(path),
+		"{monitor}", shellQuote(monitor),
+		"{fit}", shellQuote(fit),
+	).Replace(template)
+}
+
+func usesMonitor(template string) bool {
+	return strings.Contains(template, "{monitor}")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func parseMonitors(out string) []string {
+	var names []string
+	for line := range strings.Lines(out) {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		monitor  string
+		want     string
+	}{
+		{
+			name:     "path only",
+			template: "feh --bg-fill {path}",
+			path:     "/walls/a.png",
+			want:     "feh --bg-fill '/walls/a.png'",
+		},
+		{
+			name:     "all placeholders",
+			template: "swaybg -o {monitor} -i {path} -m {fit}",
+			path:     "/walls/a.png",
+			monitor:  "DP-1",
+			want:     "swaybg -o 'DP-1' -i '/walls/a.png' -m 'cover'",
+		},
+		{
+			name:     "quotes in path",
+			template: "feh --bg-fill {path}",
+			path:     "/walls/it's $HOME.png",
+			want:     `feh --bg-fill '/walls/it'\''s $HOME.png'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expand(tt.template, tt.path, tt.monitor, "cover")
+			if got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMonitors(t *testing.T) {
+	got := parseMonitors("DP-1\n  HDMI-A-1 \n\n")
+	want := []string{"DP-1", "HDMI-A-1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseMonitors() = %v, want %v", got, want)
+	}
+}
+
+func TestNewRequiresSet(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("New() succeeded without a set command")
+	}
+}
@@ -0,0 +1,320 @@
+// Package daemon implements the long-running rotation daemon and the control
+// socket the other commands use to talk to it. Every wallpaper change made by
+// a running daemon goes through a single goroutine, so timed rotations and
+// manual commands never race on the database.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Actions understood by the daemon.
+const (
+	ActionNext     = "next"
+	ActionPrevious = "previous"
+	ActionForward  = "forward"
+	ActionRandom   = "random"
+	ActionSet      = "set"
+	ActionReload   = "reload"
+)
+
+const socketName = "wallman.sock"
+
+// ErrNotRunning is returned by Send when no daemon is listening on the socket.
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Request is a single command sent over the control socket.
+type Request struct {
+	Action     string `json:"action"`
+	Monitor    string `json:"monitor,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Steps      int    `json:"steps,omitempty"`
+	TrueRandom bool   `json:"true_random,omitempty"`
+	// FavoritesOnly restricts the selection to favorites for this request.
+	FavoritesOnly bool `json:"favorites_only,omitempty"`
+	// Tags restricts the selection to wallpapers having all of these tags.
+	Tags []string `json:"tags,omitempty"`
+	// Strategy is the random strategy of a random request.
+	Strategy string `json:"strategy,omitempty"`
+	// Fit overrides the configured fit mode of a next, random or set request.
+	Fit string `json:"fit,omitempty"`
+}
+
+// Response is the daemon's answer to a Request.
+type Response struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Rotation describes what the daemon does on its own.
+type Rotation struct {
+	// Interval between two rotations. Zero disables timed rotation.
+	Interval time.Duration
+	// Request is handled every time the interval elapses.
+	Request Request
+}
+
+// Daemon rotates wallpapers on an interval and serves control requests.
+type Daemon struct {
+	// SocketPath is the unix socket the daemon listens on.
+	SocketPath string
+	// ConfigPath is watched for modifications; when it changes Reload is
+	// called before the next rotation or request. It may be empty.
+	ConfigPath string
+	// Reload (re)reads the configuration. It is called on start, on SIGHUP,
+	// on a reload request and when ConfigPath changes.
+	Reload func() (Rotation, error)
+	// Handle performs a wallpaper change.
+	Handle func(Request) error
+	// Watch, if set, runs alongside the daemon until it stops. The functions
+	// it passes to run are executed by the daemon between rotations and
+	// requests, so they never race with Handle.
+	Watch func(ctx context.Context, run func(func() error) error) error
+
+	rotation    Rotation
+	configMTime time.Time
+}
+
+type call struct {
+	req   Request
+	reply chan error
+}
+
+type job struct {
+	fn    func() error
+	reply chan error
+}
+
+// SocketPath returns the default control socket path, preferring
+// $XDG_RUNTIME_DIR and falling back to the wallman data directory.
+func SocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, socketName)
+	}
+	return filepath.Join(os.Getenv("HOME"), ".local", "share", "wallman", socketName)
+}
+
+// Send forwards a request to the daemon listening on socketPath and returns
+// the error it reported. It returns ErrNotRunning if no daemon is listening.
+func Send(socketPath string, req Request) error {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return ErrNotRunning
+	}
+	defer conn.Close()
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to daemon: %w", err)
+	}
+
+	var resp Response
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("failed to read daemon response: %w", err)
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Run serves the control socket and rotates wallpapers until ctx is done.
+func (d *Daemon) Run(ctx context.Context) error {
+	err := d.reload()
+	if err != nil {
+		return err
+	}
+
+	listener, err := d.listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(d.SocketPath)
+	defer listener.Close()
+
+	calls := make(chan call)
+	go d.accept(listener, calls)
+
+	jobs := make(chan job)
+	if d.Watch != nil {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go d.watch(watchCtx, jobs)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	timer := time.NewTimer(d.rotation.Interval)
+	if d.rotation.Interval <= 0 {
+		timer.Stop()
+	}
+	defer timer.Stop()
+	resetTimer := func() {
+		timer.Stop()
+		if d.rotation.Interval > 0 {
+			timer.Reset(d.rotation.Interval)
+		}
+	}
+
+	log.Printf("daemon listening on %s", d.SocketPath)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-hup:
+			if err := d.reload(); err != nil {
+				log.Printf("reload failed, keeping previous config: %v", err)
+			}
+			resetTimer()
+
+		case <-timer.C:
+			d.reloadIfChanged()
+			if err := d.Handle(d.rotation.Request); err != nil {
+				log.Printf("rotation failed: %v", err)
+			}
+			resetTimer()
+
+		case j := <-jobs:
+			d.reloadIfChanged()
+			j.reply <- j.fn()
+
+		case c := <-calls:
+			if c.req.Action == ActionReload {
+				c.reply <- d.reload()
+				resetTimer()
+				continue
+			}
+			d.reloadIfChanged()
+			c.reply <- d.Handle(c.req)
+			// A manual change restarts the countdown.
+			resetTimer()
+		}
+	}
+}
+
+// watch runs Watch, handing the functions it wants to run to the daemon loop.
+func (d *Daemon) watch(ctx context.Context, jobs chan<- job) {
+	run := func(fn func() error) error {
+		reply := make(chan error, 1)
+		select {
+		case jobs <- job{fn: fn, reply: reply}:
+			return <-reply
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	err := d.Watch(ctx, run)
+	if err != nil {
+		log.Printf("watch stopped: %v", err)
+	}
+}
+
+// listen creates the control socket, replacing a stale one left behind by a
+// daemon that did not shut down cleanly.
+func (d *Daemon) listen() (net.Listener, error) {
+	if _, err := os.Stat(d.SocketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", d.SocketPath, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("daemon already running on %s", d.SocketPath)
+		}
+		if err := os.Remove(d.SocketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(d.SocketPath), 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	listener, err := net.Listen("unix", d.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", d.SocketPath, err)
+	}
+	return listener, nil
+}
+
+func (d *Daemon) accept(listener net.Listener, calls chan<- call) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("failed to accept connection: %v", err)
+			continue
+		}
+		go serve(conn, calls)
+	}
+}
+
+func serve(conn net.Conn, calls chan<- call) {
+	defer conn.Close()
+
+	var req Request
+	err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req)
+	if err != nil {
+		log.Printf("invalid request: %v", err)
+		return
+	}
+
+	reply := make(chan error, 1)
+	calls <- call{req: req, reply: reply}
+
+	var resp Response
+	if err := <-reply; err != nil {
+		resp.Error = err.Error()
+	}
+	err = json.NewEncoder(conn).Encode(resp)
+	if err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+func (d *Daemon) reload() error {
+	rotation, err := d.Reload()
+	if err != nil {
+		return err
+	}
+	d.rotation = rotation
+	d.configMTime = d.configModTime()
+	return nil
+}
+
+func (d *Daemon) reloadIfChanged() {
+	mtime := d.configModTime()
+	if d.ConfigPath == "" || mtime.Equal(d.configMTime) {
+		return
+	}
+	// Remember the broken version too, so it is only reported once.
+	d.configMTime = mtime
+	if err := d.reload(); err != nil {
+		log.Printf("reload failed, keeping previous config: %v", err)
+	}
+}
+
+func (d *Daemon) configModTime() time.Time {
+	if d.ConfigPath == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(d.ConfigPath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
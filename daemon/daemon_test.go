@@ -0,0 +1,155 @@
+package daemon_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/daemon"
+)
+
+func startDaemon(t *testing.T, interval time.Duration, handle func(daemon.Request) error) (string, *atomic.Int32) {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "wallman.sock")
+	reloads := &atomic.Int32{}
+	d := &daemon.Daemon{
+		SocketPath: socketPath,
+		Reload: func() (daemon.Rotation, error) {
+			reloads.Add(1)
+			return daemon.Rotation{
+				Interval: interval,
+				Request:  daemon.Request{Action: daemon.ActionRandom},
+			}, nil
+		},
+		Handle: handle,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() failed: %v", err)
+		}
+	})
+
+	// Wait for the socket to accept connections.
+	for range 100 {
+		if err := daemon.Send(socketPath, daemon.Request{Action: daemon.ActionReload}); err == nil {
+			return socketPath, reloads
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("daemon did not start")
+	return "", nil
+}
+
+func TestSendNotRunning(t *testing.T) {
+	err := daemon.Send(filepath.Join(t.TempDir(), "missing.sock"), daemon.Request{Action: daemon.ActionNext})
+	if !errors.Is(err, daemon.ErrNotRunning) {
+		t.Errorf("Send() error = %v, want %v", err, daemon.ErrNotRunning)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	handled := make(chan daemon.Request, 10)
+	socketPath, reloads := startDaemon(t, 0, func(req daemon.Request) error {
+		if req.Path == "broken.png" {
+			return errors.New("broken wallpaper")
+		}
+		handled <- req
+		return nil
+	})
+
+	want := daemon.Request{Action: daemon.ActionSet, Monitor: "DP-1", Path: "/walls/a.png", Tags: []string{"nature"}}
+	if err := daemon.Send(socketPath, want); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if got := <-handled; !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %+v, want %+v", got, want)
+	}
+
+	err := daemon.Send(socketPath, daemon.Request{Action: daemon.ActionSet, Path: "broken.png"})
+	if err == nil || err.Error() != "broken wallpaper" {
+		t.Errorf("Send() error = %v, want the handler error", err)
+	}
+
+	before := reloads.Load()
+	if err := daemon.Send(socketPath, daemon.Request{Action: daemon.ActionReload}); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if got := reloads.Load(); got != before+1 {
+		t.Errorf("reloads = %d, want %d", got, before+1)
+	}
+	if len(handled) != 0 {
+		t.Errorf("reload reached the handler")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	handled := make(chan daemon.Request, 10)
+	startDaemon(t, 20*time.Millisecond, func(req daemon.Request) error {
+		handled <- req
+		return nil
+	})
+
+	select {
+	case req := <-handled:
+		if req.Action != daemon.ActionRandom {
+			t.Errorf("rotated with %q, want %q", req.Action, daemon.ActionRandom)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon did not rotate")
+	}
+}
+
+func TestAlreadyRunning(t *testing.T) {
+	socketPath, _ := startDaemon(t, 0, func(daemon.Request) error { return nil })
+
+	d := &daemon.Daemon{
+		SocketPath: socketPath,
+		Reload:     func() (daemon.Rotation, error) { return daemon.Rotation{}, nil },
+		Handle:     func(daemon.Request) error { return nil },
+	}
+	if err := d.Run(context.Background()); err == nil {
+		t.Error("Run() succeeded with a daemon already listening")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ran := make(chan error, 1)
+	d := &daemon.Daemon{
+		SocketPath: filepath.Join(t.TempDir(), "wallman.sock"),
+		Reload:     func() (daemon.Rotation, error) { return daemon.Rotation{}, nil },
+		Handle:     func(daemon.Request) error { return nil },
+		Watch: func(ctx context.Context, run func(func() error) error) error {
+			ran <- run(func() error { return errors.New("from the loop") })
+			<-ctx.Done()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	select {
+	case err := <-ran:
+		if err == nil || err.Error() != "from the loop" {
+			t.Errorf("run() = %v, want the error of the function", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch function was not run")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() failed: %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/marcosalvi-01/wallman/db/sqlc"
 
@@ -49,16 +50,61 @@ func init() {
 	goose.SetLogger(&gooseLogger{})
 }
 
-// Get opens a new connection to the database, creating the database file and schema if they do not exist.
+// Get returns the queries on the database, creating the database file and schema if they do not exist.
 func Get() (*sqlc.Queries, error) {
+	db, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return sqlc.New(db), nil
+}
+
+var (
+	// mu guards conn.
+	mu sync.Mutex
+	// conn is the database opened by open, shared by every caller so that
+	// a long running daemon does not leak connections.
+	conn *sql.DB
+)
+
+// open returns the database behind Get, for the callers that need
+// transactions. The database is opened and migrated once and then reused
+// until Close; callers must not close it.
+func open() (*sql.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if conn != nil {
+		return conn, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("error getting home dir: %w", err)
 	}
 	dbDir := filepath.Join(homeDir, ".local", "share", "wallman")
-	dbFile := filepath.Join(dbDir, dbName)
+	db, err := connect(dbDir, filepath.Join(dbDir, dbName))
+	if err != nil {
+		return nil, err
+	}
+	conn = db
+	return db, nil
+}
+
+// Close closes the database. The next Get opens it again.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	err := conn.Close()
+	conn = nil
+	return err
+}
 
-	err = os.MkdirAll(dbDir, 0o750)
+// connect opens dbFile, creating it in dbDir if needed, and migrates it.
+func connect(dbDir, dbFile string) (*sql.DB, error) {
+	err := os.MkdirAll(dbDir, 0o750)
 	if err != nil {
 		return nil, fmt.Errorf("error creating db directory %s: %w", dbDir, err)
 	}
@@ -96,8 +142,7 @@ func Get() (*sqlc.Queries, error) {
 		return nil, err
 	}
 
-	queries := sqlc.New(db)
-	return queries, nil
+	return db, nil
 }
 
 func runMigrations(db *sql.DB) error {
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+// newHome points HOME to an empty directory for the rest of the test, with a
+// database of its own.
+func newHome(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	closeDB := func() {
+		if err := Close(); err != nil {
+			t.Errorf("Close() failed: %v", err)
+		}
+	}
+	closeDB()
+	t.Cleanup(closeDB)
+}
+
+func TestOpenReusesTheDatabase(t *testing.T) {
+	newHome(t)
+
+	first, err := open()
+	if err != nil {
+		t.Fatalf("open() failed: %v", err)
+	}
+	for range 50 {
+		if _, err := GetCurrentWallpaperPath(""); err == nil {
+			t.Fatal("GetCurrentWallpaperPath() found a wallpaper in an empty database")
+		}
+	}
+	again, err := open()
+	if err != nil {
+		t.Fatalf("open() failed: %v", err)
+	}
+	if again != first {
+		t.Error("open() opened the database again")
+	}
+	if stats := first.Stats(); stats.OpenConnections > 2 {
+		t.Errorf("%d open connections, want the pool to be reused", stats.OpenConnections)
+	}
+
+	// Closing it opens it again on the next call.
+	if err := Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	reopened, err := open()
+	if err != nil {
+		t.Fatalf("open() failed: %v", err)
+	}
+	if reopened == first {
+		t.Error("open() returned the closed database")
+	}
+}
+
+func TestMigrateLocalTimestamps(t *testing.T) {
+	newHome(t)
+
+	database, err := open()
+	if err != nil {
+		t.Fatalf("open() failed: %v", err)
+	}
+	if err := goose.DownTo(database, "migrations", 12); err != nil {
+		t.Fatalf("DownTo() failed: %v", err)
+	}
+	// a.png was set at 22:30 UTC, before b.png at 23:00 UTC, but its local
+	// time sorts after it as text.
+	_, err = database.Exec(`
+		INSERT INTO wallpaper_history (path, monitor, fit, set_at, unset_at) VALUES
+			('a.png', '', '', '2026-01-02 00:30:05.5 +0200 CEST', '2026-01-01 23:00:00 +0000 UTC'),
+			('b.png', '', '', '2026-01-01 23:00:00 +0000 UTC', NULL);
+		INSERT INTO current_wallpaper (monitor, path, set_at) VALUES ('', 'b.png', '2026-01-01 23:00:00 +0000 UTC');
+	`)
+	if err != nil {
+		t.Fatalf("inserting the history failed: %v", err)
+	}
+	if err := goose.Up(database, "migrations"); err != nil {
+		t.Fatalf("Up() failed: %v", err)
+	}
+
+	var setAt string
+	if err := database.QueryRow(`SELECT set_at || '' FROM wallpaper_history WHERE path = 'a.png'`).Scan(&setAt); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2026-01-01 22:30:05.5 +0000 UTC"; setAt != want {
+		t.Errorf("set_at = %q, want %q", setAt, want)
+	}
+	prev, err := GetPreviousWallpaper("", 1)
+	if err != nil {
+		t.Fatalf("GetPreviousWallpaper() failed: %v", err)
+	}
+	if prev.Path != "a.png" {
+		t.Errorf("GetPreviousWallpaper() = %s, want a.png", prev.Path)
+	}
+}
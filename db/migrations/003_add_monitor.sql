@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE wallpaper_history ADD COLUMN monitor TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_wallpaper_history_monitor ON wallpaper_history (monitor);
+
+-- An empty monitor means the wallpaper is shared by every output.
+CREATE TABLE current_wallpaper_new (
+    monitor TEXT PRIMARY KEY,
+    path TEXT NOT NULL,
+    set_at DATETIME NOT NULL
+);
+
+INSERT INTO current_wallpaper_new (monitor, path, set_at)
+SELECT '', path, set_at FROM current_wallpaper WHERE id = 1;
+
+DROP TABLE current_wallpaper;
+
+ALTER TABLE current_wallpaper_new RENAME TO current_wallpaper;
+
+-- +goose Down
+CREATE TABLE current_wallpaper_old (
+    id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL,
+    set_at DATETIME NOT NULL
+);
+
+INSERT INTO current_wallpaper_old (id, path, set_at)
+SELECT 1, path, set_at FROM current_wallpaper WHERE monitor = '';
+
+DROP TABLE current_wallpaper;
+
+ALTER TABLE current_wallpaper_old RENAME TO current_wallpaper;
+
+DROP INDEX idx_wallpaper_history_monitor;
+
+ALTER TABLE wallpaper_history DROP COLUMN monitor;
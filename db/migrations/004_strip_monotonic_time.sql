@@ -0,0 +1,17 @@
+-- +goose Up
+-- Timestamps used to be stored with Go's monotonic clock suffix (" m=+0.0012"),
+-- which breaks comparing the set_at of the current wallpaper with history.
+UPDATE wallpaper_history
+SET set_at = substr(set_at, 1, instr(set_at, ' m=') - 1)
+WHERE instr(set_at, ' m=') > 0;
+
+UPDATE wallpaper_history
+SET unset_at = substr(unset_at, 1, instr(unset_at, ' m=') - 1)
+WHERE instr(unset_at, ' m=') > 0;
+
+UPDATE current_wallpaper
+SET set_at = substr(set_at, 1, instr(set_at, ' m=') - 1)
+WHERE instr(set_at, ' m=') > 0;
+
+-- +goose Down
+SELECT 1;
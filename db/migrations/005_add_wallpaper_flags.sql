@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE wallpaper_flags (
+    path TEXT PRIMARY KEY,
+    favorite BOOLEAN NOT NULL DEFAULT FALSE,
+    banned BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- +goose Down
+DROP TABLE wallpaper_flags;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE wallpaper_tags (
+    path TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (path, tag)
+);
+
+CREATE INDEX idx_wallpaper_tags_tag ON wallpaper_tags (tag);
+
+-- +goose Down
+DROP INDEX idx_wallpaper_tags_tag;
+DROP TABLE wallpaper_tags;
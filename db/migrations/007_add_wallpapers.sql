@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE wallpapers (
+    root TEXT NOT NULL,
+    path TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    mtime DATETIME NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (root, path)
+);
+
+CREATE TABLE library_scans (
+    root TEXT PRIMARY KEY,
+    recursive BOOLEAN NOT NULL,
+    scanned_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE library_scans;
+DROP TABLE wallpapers;
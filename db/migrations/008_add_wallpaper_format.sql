@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE wallpapers ADD COLUMN format TEXT NOT NULL DEFAULT '';
+
+-- The index is a cache: drop it so that every file is sniffed again.
+DELETE FROM wallpapers;
+DELETE FROM library_scans;
+
+-- +goose Down
+ALTER TABLE wallpapers DROP COLUMN format;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- The fit mode the wallpaper was shown with, empty for entries made before
+-- it was recorded.
+ALTER TABLE wallpaper_history ADD COLUMN fit TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE wallpaper_history DROP COLUMN fit;
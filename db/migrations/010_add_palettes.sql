@@ -0,0 +1,13 @@
+-- +goose Up
+-- The dominant colors of a wallpaper as a JSON array, valid as long as the
+-- size and modification time of the file match.
+CREATE TABLE palettes (
+    path TEXT PRIMARY KEY,
+    size INTEGER NOT NULL,
+    mtime DATETIME NOT NULL,
+    count INTEGER NOT NULL,
+    colors TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE palettes;
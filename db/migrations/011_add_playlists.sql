@@ -0,0 +1,22 @@
+-- +goose Up
+-- A playlist keeps its own place for next and previous, the position of the
+-- item shown last, and its own random cycle like random_cycle does.
+CREATE TABLE playlists (
+    name TEXT PRIMARY KEY,
+    position INTEGER NOT NULL DEFAULT 0,
+    shuffled_wallpapers TEXT NOT NULL DEFAULT '[]',
+    current_index INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- Items are played by increasing position, starting at 1.
+CREATE TABLE playlist_items (
+    playlist TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    PRIMARY KEY (playlist, path)
+);
+
+-- +goose Down
+DROP TABLE playlist_items;
+DROP TABLE playlists;
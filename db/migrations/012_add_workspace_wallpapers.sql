@@ -0,0 +1,10 @@
+-- +goose Up
+-- The wallpapers set with 'wallman workspace set', shown whenever Hyprland
+-- switches a monitor to the workspace.
+CREATE TABLE workspace_wallpapers (
+    workspace TEXT PRIMARY KEY,
+    path TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE workspace_wallpapers;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Timestamps used to be stored in local time ("2026-01-02 15:04:05.5 +0200 CEST")
+-- while they are compared as text, so rows of another offset sort wrong.
+-- Rewrite them in UTC, as they are stored now. The offset follows the
+-- fractional seconds, if any, and SQLite shifts a time suffixed with "+HH:MM".
+UPDATE wallpaper_history
+SET set_at = datetime(substr(set_at, 1, 19) || substr(set_at, instr(substr(set_at, 20), ' ') + 20, 3) || ':' || substr(set_at, instr(substr(set_at, 20), ' ') + 23, 2))
+    || substr(set_at, 20, instr(substr(set_at, 20), ' ') - 1) || ' +0000 UTC'
+WHERE set_at NOT LIKE '% UTC';
+
+UPDATE wallpaper_history
+SET unset_at = datetime(substr(unset_at, 1, 19) || substr(unset_at, instr(substr(unset_at, 20), ' ') + 20, 3) || ':' || substr(unset_at, instr(substr(unset_at, 20), ' ') + 23, 2))
+    || substr(unset_at, 20, instr(substr(unset_at, 20), ' ') - 1) || ' +0000 UTC'
+WHERE unset_at NOT LIKE '% UTC';
+
+UPDATE current_wallpaper
+SET set_at = datetime(substr(set_at, 1, 19) || substr(set_at, instr(substr(set_at, 20), ' ') + 20, 3) || ':' || substr(set_at, instr(substr(set_at, 20), ' ') + 23, 2))
+    || substr(set_at, 20, instr(substr(set_at, 20), ' ') - 1) || ' +0000 UTC'
+WHERE set_at NOT LIKE '% UTC';
+
+-- +goose Down
+SELECT 1;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The SHA-256 of the content of a file, hex encoded, valid as long as the
+-- size and modification time of the file match.
+CREATE TABLE file_hashes (
+    path TEXT PRIMARY KEY,
+    size INTEGER NOT NULL,
+    mtime DATETIME NOT NULL,
+    hash TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE file_hashes;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- A rating from 1 to 5 stars, 0 when the wallpaper is not rated.
+ALTER TABLE wallpaper_flags ADD COLUMN rating INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE wallpaper_flags DROP COLUMN rating;
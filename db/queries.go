@@ -10,44 +10,43 @@ import (
 	"github.com/marcosalvi-01/wallman/db/sqlc"
 )
 
-// SetWallpaper sets the current wallpaper and updates history.
-func SetWallpaper(path string) error {
+// SetWallpaper sets the current wallpaper of a monitor and updates history,
+// recording the fit mode it is shown with. An empty monitor sets the wallpaper
+// shared by every output, replacing any per-monitor wallpapers.
+func SetWallpaper(path, monitor, fit string) error {
 	q, err := Get()
 	if err != nil {
 		return fmt.Errorf("error getting db connection: %w", err)
 	}
 
 	ctx := context.Background()
-	now := time.Now()
-
-	// Mark previous as unset
-	current, err := q.GetCurrentWallpaper(ctx)
-	if err == nil {
-		err = q.MarkWallpaperUnset(ctx, sqlc.MarkWallpaperUnsetParams{
-			UnsetAt: &now,
-			Path:    current.Path,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to mark previous unset: %w", err)
-		}
-	} else if err != sql.ErrNoRows {
-		return fmt.Errorf("failed to get current: %w", err)
+	// Timestamps are compared as text, so store them in UTC, which also drops
+	// the monotonic clock reading that would otherwise end up in the column.
+	now := time.Now().UTC()
+
+	// Mark previous as unset, the shared wallpaper included: a monitor
+	// getting its own wallpaper no longer shows it.
+	err = q.MarkWallpaperUnset(ctx, sqlc.MarkWallpaperUnsetParams{
+		UnsetAt: &now,
+		Monitor: monitor,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark previous unset: %w", err)
 	}
 
 	// Insert new history
 	err = q.InsertWallpaperHistory(ctx, sqlc.InsertWallpaperHistoryParams{
-		Path:  path,
-		SetAt: now,
+		Path:    path,
+		Monitor: monitor,
+		Fit:     fit,
+		SetAt:   now,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to insert history: %w", err)
 	}
 
 	// Update current
-	_, err = q.UpdateCurrentWallpaper(ctx, sqlc.UpdateCurrentWallpaperParams{
-		Path:  path,
-		SetAt: now,
-	})
+	err = updateCurrent(ctx, q, path, monitor, now)
 	if err != nil {
 		return fmt.Errorf("failed to update current: %w", err)
 	}
@@ -55,36 +54,49 @@ func SetWallpaper(path string) error {
 	return nil
 }
 
-// GetCurrentWallpaperPath returns the current wallpaper path.
-func GetCurrentWallpaperPath() (string, error) {
+// GetCurrentWallpaperPath returns the current wallpaper path of a monitor,
+// falling back to the shared wallpaper when the monitor has none of its own.
+func GetCurrentWallpaperPath(monitor string) (string, error) {
+	current, err := GetCurrentWallpaper(monitor)
+	if err != nil {
+		return "", err
+	}
+	return current.Path, nil
+}
+
+// GetCurrentWallpaper is GetCurrentWallpaperPath returning when the wallpaper
+// was set too. Monitor is empty when the shared wallpaper is returned.
+func GetCurrentWallpaper(monitor string) (sqlc.CurrentWallpaper, error) {
 	q, err := Get()
 	if err != nil {
-		return "", fmt.Errorf("error getting db connection: %w", err)
+		return sqlc.CurrentWallpaper{}, fmt.Errorf("error getting db connection: %w", err)
 	}
 
-	current, err := q.GetCurrentWallpaper(context.Background())
+	current, err := q.GetCurrentWallpaper(context.Background(), monitor)
 	if err == sql.ErrNoRows {
-		return "", fmt.Errorf("no current wallpaper set")
+		return sqlc.CurrentWallpaper{}, fmt.Errorf("no current wallpaper set")
 	}
 	if err != nil {
-		return "", fmt.Errorf("error getting current wallpaper: %w", err)
+		return sqlc.CurrentWallpaper{}, fmt.Errorf("error getting current wallpaper: %w", err)
 	}
-	return current.Path, nil
+	return current, nil
 }
 
-// GetWallpaperHistory returns the wallpaper history.
-func GetWallpaperHistory(limit int) ([]sqlc.WallpaperHistory, error) {
+// GetWallpaperHistory returns the wallpaper history. A non-empty monitor
+// restricts it to the entries that were shown on that monitor.
+func GetWallpaperHistory(monitor string, limit int) ([]sqlc.WallpaperHistory, error) {
 	q, err := Get()
 	if err != nil {
 		return nil, fmt.Errorf("error getting db connection: %w", err)
 	}
 
 	history, err := q.GetWallpaperHistory(context.Background(), sqlc.GetWallpaperHistoryParams{
-		Column1: nil,
-		SetAt:   time.Time{},
-		Column3: nil,
-		ID:      0,
-		Limit:   int64(limit),
+		Column1:    nil,
+		SetAt:      time.Time{},
+		Column3:    nil,
+		ID:         0,
+		Monitor:    monitor,
+		MaxEntries: int64(limit),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting wallpaper history: %w", err)
@@ -92,34 +104,63 @@ func GetWallpaperHistory(limit int) ([]sqlc.WallpaperHistory, error) {
 	return history, nil
 }
 
-// GetPreviousWallpaper returns the previous wallpaper path and set time.
-func GetPreviousWallpaper() (string, time.Time, error) {
+// GetPreviousWallpaper returns the history entry shown steps entries before
+// the current one of a monitor.
+func GetPreviousWallpaper(monitor string, steps int) (sqlc.WallpaperHistory, error) {
 	q, err := Get()
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("error getting db connection: %w", err)
+		return sqlc.WallpaperHistory{}, fmt.Errorf("error getting db connection: %w", err)
 	}
 
-	prev, err := q.GetPreviousWallpaper(context.Background())
+	prev, err := q.GetPreviousWallpaper(context.Background(), sqlc.GetPreviousWallpaperParams{
+		Monitor: monitor,
+		Skip:    int64(steps - 1),
+	})
 	if err == sql.ErrNoRows {
-		return "", time.Time{}, fmt.Errorf("no previous wallpaper")
+		if steps > 1 {
+			return sqlc.WallpaperHistory{}, fmt.Errorf("history has fewer than %d previous wallpapers", steps)
+		}
+		return sqlc.WallpaperHistory{}, fmt.Errorf("no previous wallpaper")
 	}
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("error getting previous wallpaper: %w", err)
+		return sqlc.WallpaperHistory{}, fmt.Errorf("error getting previous wallpaper: %w", err)
 	}
-	return prev.Path, prev.SetAt, nil
+	return prev, nil
 }
 
-// SetCurrentWallpaper updates the current wallpaper without modifying history.
-func SetCurrentWallpaper(path string, setAt time.Time) error {
+// GetNextWallpaper returns the history entry shown steps entries after the
+// current one of a monitor, i.e. the ones left behind by going back in
+// history.
+func GetNextWallpaper(monitor string, steps int) (sqlc.WallpaperHistory, error) {
 	q, err := Get()
 	if err != nil {
-		return fmt.Errorf("error getting db connection: %w", err)
+		return sqlc.WallpaperHistory{}, fmt.Errorf("error getting db connection: %w", err)
 	}
 
-	_, err = q.UpdateCurrentWallpaper(context.Background(), sqlc.UpdateCurrentWallpaperParams{
-		Path:  path,
-		SetAt: setAt,
+	next, err := q.GetNextWallpaper(context.Background(), sqlc.GetNextWallpaperParams{
+		Monitor: monitor,
+		Skip:    int64(steps - 1),
 	})
+	if err == sql.ErrNoRows {
+		if steps > 1 {
+			return sqlc.WallpaperHistory{}, fmt.Errorf("history has fewer than %d newer wallpapers", steps)
+		}
+		return sqlc.WallpaperHistory{}, fmt.Errorf("no newer wallpaper in history")
+	}
+	if err != nil {
+		return sqlc.WallpaperHistory{}, fmt.Errorf("error getting next wallpaper: %w", err)
+	}
+	return next, nil
+}
+
+// SetCurrentWallpaper updates the current wallpaper of a monitor without modifying history.
+func SetCurrentWallpaper(path, monitor string, setAt time.Time) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = updateCurrent(context.Background(), q, path, monitor, setAt)
 	if err != nil {
 		return fmt.Errorf("failed to update current wallpaper: %w", err)
 	}
@@ -127,6 +168,24 @@ func SetCurrentWallpaper(path string, setAt time.Time) error {
 	return nil
 }
 
+// updateCurrent stores the current wallpaper of a monitor. Setting the shared
+// wallpaper drops the per-monitor ones, since it now covers every output.
+func updateCurrent(ctx context.Context, q *sqlc.Queries, path, monitor string, setAt time.Time) error {
+	if monitor == "" {
+		err := q.ClearMonitorWallpapers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to clear monitor wallpapers: %w", err)
+		}
+	}
+
+	_, err := q.UpdateCurrentWallpaper(ctx, sqlc.UpdateCurrentWallpaperParams{
+		Monitor: monitor,
+		Path:    path,
+		SetAt:   setAt.UTC(),
+	})
+	return err
+}
+
 // GetRandomCycle returns the current random cycle state.
 func GetRandomCycle() (shuffled []string, index int, err error) {
 	q, err := Get()
@@ -170,3 +229,659 @@ func UpsertRandomCycle(shuffled []string, index int) error {
 	}
 	return nil
 }
+
+// SetFavorite marks or unmarks a wallpaper as favorite.
+func SetFavorite(path string, favorite bool) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	ctx := context.Background()
+	err = q.SetWallpaperFavorite(ctx, sqlc.SetWallpaperFavoriteParams{
+		Path:     path,
+		Favorite: favorite,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting favorite: %w", err)
+	}
+
+	err = q.DeleteUnflaggedWallpapers(ctx)
+	if err != nil {
+		return fmt.Errorf("error cleaning up wallpaper flags: %w", err)
+	}
+	return nil
+}
+
+// SetBanned adds or removes a wallpaper from the ban list.
+func SetBanned(path string, banned bool) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	ctx := context.Background()
+	err = q.SetWallpaperBanned(ctx, sqlc.SetWallpaperBannedParams{
+		Path:   path,
+		Banned: banned,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting banned: %w", err)
+	}
+
+	err = q.DeleteUnflaggedWallpapers(ctx)
+	if err != nil {
+		return fmt.Errorf("error cleaning up wallpaper flags: %w", err)
+	}
+	return nil
+}
+
+// SetRating rates a wallpaper from 1 to 5 stars, 0 removing its rating.
+func SetRating(path string, rating int) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	ctx := context.Background()
+	err = q.SetWallpaperRating(ctx, sqlc.SetWallpaperRatingParams{
+		Path:   path,
+		Rating: int64(rating),
+	})
+	if err != nil {
+		return fmt.Errorf("error setting rating: %w", err)
+	}
+
+	err = q.DeleteUnflaggedWallpapers(ctx)
+	if err != nil {
+		return fmt.Errorf("error cleaning up wallpaper flags: %w", err)
+	}
+	return nil
+}
+
+// GetWallpaperFlags returns the favorite and banned flags and the rating of every flagged wallpaper, by path.
+func GetWallpaperFlags() (map[string]sqlc.WallpaperFlag, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	rows, err := q.ListWallpaperFlags(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting wallpaper flags: %w", err)
+	}
+
+	flags := make(map[string]sqlc.WallpaperFlag, len(rows))
+	for _, row := range rows {
+		flags[row.Path] = row
+	}
+	return flags, nil
+}
+
+// AddTag tags a wallpaper. Adding a tag twice is a no-op.
+func AddTag(path, tag string) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.AddWallpaperTag(context.Background(), sqlc.AddWallpaperTagParams{
+		Path: path,
+		Tag:  tag,
+	})
+	if err != nil {
+		return fmt.Errorf("error adding tag: %w", err)
+	}
+	return nil
+}
+
+// RemoveTag removes a tag from a wallpaper.
+func RemoveTag(path, tag string) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.RemoveWallpaperTag(context.Background(), sqlc.RemoveWallpaperTagParams{
+		Path: path,
+		Tag:  tag,
+	})
+	if err != nil {
+		return fmt.Errorf("error removing tag: %w", err)
+	}
+	return nil
+}
+
+// GetWallpaperTags returns the stored tags of every tagged wallpaper, by path.
+func GetWallpaperTags() (map[string][]string, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	rows, err := q.ListWallpaperTags(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting wallpaper tags: %w", err)
+	}
+
+	tags := make(map[string][]string)
+	for _, row := range rows {
+		tags[row.Path] = append(tags[row.Path], row.Tag)
+	}
+	return tags, nil
+}
+
+// GetLastShown returns when every wallpaper in the history was last set, on any monitor, by path.
+func GetLastShown() (map[string]time.Time, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	rows, err := q.ListLastShown(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting last shown wallpapers: %w", err)
+	}
+
+	shown := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		shown[row.Path] = row.SetAt
+	}
+	return shown, nil
+}
+
+// GetLibrary returns the indexed wallpapers of a wallpaper directory, sorted by path.
+func GetLibrary(root string) ([]sqlc.Wallpaper, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	walls, err := q.ListLibrary(context.Background(), root)
+	if err != nil {
+		return nil, fmt.Errorf("error getting library: %w", err)
+	}
+	return walls, nil
+}
+
+// GetLibraryScan returns when a wallpaper directory was last indexed. found is
+// false if it never was.
+func GetLibraryScan(root string) (scan sqlc.LibraryScan, found bool, err error) {
+	q, err := Get()
+	if err != nil {
+		return sqlc.LibraryScan{}, false, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	scan, err = q.GetLibraryScan(context.Background(), root)
+	if err == sql.ErrNoRows {
+		return sqlc.LibraryScan{}, false, nil
+	}
+	if err != nil {
+		return sqlc.LibraryScan{}, false, fmt.Errorf("error getting library scan: %w", err)
+	}
+	return scan, true, nil
+}
+
+// UpdateLibrary stores the result of indexing a wallpaper directory: changed
+// wallpapers are added or replaced, removed paths are deleted. It runs in a
+// single transaction since a scan can touch thousands of rows.
+func UpdateLibrary(root string, recursive bool, changed []sqlc.Wallpaper, removed []string, scannedAt time.Time) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		for _, wall := range changed {
+			err := q.UpsertLibraryWallpaper(ctx, sqlc.UpsertLibraryWallpaperParams{
+				Root:   root,
+				Path:   wall.Path,
+				Size:   wall.Size,
+				Mtime:  wall.Mtime.UTC(),
+				Width:  wall.Width,
+				Height: wall.Height,
+				Format: wall.Format,
+			})
+			if err != nil {
+				return fmt.Errorf("error indexing %s: %w", wall.Path, err)
+			}
+		}
+		for _, path := range removed {
+			err := q.DeleteLibraryWallpaper(ctx, sqlc.DeleteLibraryWallpaperParams{
+				Root: root,
+				Path: path,
+			})
+			if err != nil {
+				return fmt.Errorf("error removing %s from the library: %w", path, err)
+			}
+		}
+
+		err := q.UpsertLibraryScan(ctx, sqlc.UpsertLibraryScanParams{
+			Root:      root,
+			Recursive: recursive,
+			ScannedAt: scannedAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("error recording library scan: %w", err)
+		}
+		return nil
+	})
+}
+
+// RenameWallpaper moves everything stored about a wallpaper to its new path:
+// history, current wallpapers, flags, tags, palette, content hash, playlist
+// items and workspace wallpapers.
+func RenameWallpaper(oldPath, newPath string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		if err := q.RenameHistoryPath(ctx, sqlc.RenameHistoryPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming history: %w", err)
+		}
+		if err := q.RenameCurrentPath(ctx, sqlc.RenameCurrentPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming current wallpaper: %w", err)
+		}
+		if err := q.RenameFlagsPath(ctx, sqlc.RenameFlagsPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming flags: %w", err)
+		}
+		if err := q.RenameTagsPath(ctx, sqlc.RenameTagsPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming tags: %w", err)
+		}
+		// Tags the new path already had are left behind by the rename.
+		if err := q.DeleteTagsPath(ctx, oldPath); err != nil {
+			return fmt.Errorf("error renaming tags: %w", err)
+		}
+		if err := q.RenamePalettePath(ctx, sqlc.RenamePalettePathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming palette: %w", err)
+		}
+		if err := q.RenameFileHashPath(ctx, sqlc.RenameFileHashPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming content hash: %w", err)
+		}
+		if err := q.RenamePlaylistItemsPath(ctx, sqlc.RenamePlaylistItemsPathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming playlist items: %w", err)
+		}
+		// Like tags, items of playlists already holding the new path are left
+		// behind.
+		if err := q.DeletePlaylistItemsPath(ctx, oldPath); err != nil {
+			return fmt.Errorf("error renaming playlist items: %w", err)
+		}
+		if err := q.RenameWorkspacePath(ctx, sqlc.RenameWorkspacePathParams{NewPath: newPath, OldPath: oldPath}); err != nil {
+			return fmt.Errorf("error renaming workspace wallpapers: %w", err)
+		}
+		return nil
+	})
+}
+
+// ForgetWallpaper drops a wallpaper that no longer exists from the history
+// and the current wallpapers. Its flags, tags, palette, playlist items and
+// workspaces are kept for when it comes back, e.g. after a remount; only the
+// indexed wallpapers are ever picked.
+func ForgetWallpaper(path string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		if err := q.DeleteHistoryPath(ctx, path); err != nil {
+			return fmt.Errorf("error deleting history: %w", err)
+		}
+		if err := q.DeleteCurrentPath(ctx, path); err != nil {
+			return fmt.Errorf("error deleting current wallpaper: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPalette returns the cached palette of a wallpaper, reporting whether
+// there is one.
+func GetPalette(path string) (sqlc.Palette, bool, error) {
+	q, err := Get()
+	if err != nil {
+		return sqlc.Palette{}, false, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	palette, err := q.GetPalette(context.Background(), path)
+	if err == sql.ErrNoRows {
+		return sqlc.Palette{}, false, nil
+	}
+	if err != nil {
+		return sqlc.Palette{}, false, fmt.Errorf("error getting palette: %w", err)
+	}
+	return palette, true, nil
+}
+
+// SetPalette caches the palette of a wallpaper.
+func SetPalette(palette sqlc.Palette) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.UpsertPalette(context.Background(), sqlc.UpsertPaletteParams(palette))
+	if err != nil {
+		return fmt.Errorf("error saving palette: %w", err)
+	}
+	return nil
+}
+
+// GetFileHash returns the cached content hash of a file, reporting whether
+// there is one.
+func GetFileHash(path string) (sqlc.FileHash, bool, error) {
+	q, err := Get()
+	if err != nil {
+		return sqlc.FileHash{}, false, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	hash, err := q.GetFileHash(context.Background(), path)
+	if err == sql.ErrNoRows {
+		return sqlc.FileHash{}, false, nil
+	}
+	if err != nil {
+		return sqlc.FileHash{}, false, fmt.Errorf("error getting content hash: %w", err)
+	}
+	return hash, true, nil
+}
+
+// SetFileHash caches the content hash of a file.
+func SetFileHash(hash sqlc.FileHash) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.UpsertFileHash(context.Background(), sqlc.UpsertFileHashParams(hash))
+	if err != nil {
+		return fmt.Errorf("error saving content hash: %w", err)
+	}
+	return nil
+}
+
+// CreatePlaylist creates an empty playlist.
+func CreatePlaylist(name string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		_, err := q.GetPlaylist(ctx, name)
+		if err == nil {
+			return fmt.Errorf("playlist %s already exists", name)
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("error getting playlist: %w", err)
+		}
+		if err := q.CreatePlaylist(ctx, name); err != nil {
+			return fmt.Errorf("error creating playlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPlaylist returns a playlist, reporting whether it exists.
+func GetPlaylist(name string) (sqlc.Playlist, bool, error) {
+	q, err := Get()
+	if err != nil {
+		return sqlc.Playlist{}, false, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	playlist, err := q.GetPlaylist(context.Background(), name)
+	if err == sql.ErrNoRows {
+		return sqlc.Playlist{}, false, nil
+	}
+	if err != nil {
+		return sqlc.Playlist{}, false, fmt.Errorf("error getting playlist: %w", err)
+	}
+	return playlist, true, nil
+}
+
+// GetPlaylists returns every playlist, by name.
+func GetPlaylists() ([]sqlc.Playlist, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	playlists, err := q.ListPlaylists(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting playlists: %w", err)
+	}
+	return playlists, nil
+}
+
+// GetActivePlaylist returns the name of the playlist in use, empty if none is.
+func GetActivePlaylist() (string, error) {
+	playlists, err := GetPlaylists()
+	if err != nil {
+		return "", err
+	}
+	for _, playlist := range playlists {
+		if playlist.Active {
+			return playlist.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// UsePlaylist makes name the playlist in use. An empty name stops using
+// playlists.
+func UsePlaylist(name string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		if name != "" {
+			if err := playlistExists(ctx, q, name); err != nil {
+				return err
+			}
+		}
+		if err := q.SetActivePlaylist(ctx, name); err != nil {
+			return fmt.Errorf("error setting active playlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPlaylistItems returns the items of a playlist in playing order.
+func GetPlaylistItems(name string) ([]sqlc.PlaylistItem, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	items, err := q.ListPlaylistItems(context.Background(), name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting playlist items: %w", err)
+	}
+	return items, nil
+}
+
+// AddToPlaylist appends paths to a playlist. Paths already in it keep their
+// place.
+func AddToPlaylist(name string, paths []string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		if err := playlistExists(ctx, q, name); err != nil {
+			return err
+		}
+		items, err := q.ListPlaylistItems(ctx, name)
+		if err != nil {
+			return fmt.Errorf("error getting playlist items: %w", err)
+		}
+		var last int64
+		if len(items) > 0 {
+			last = items[len(items)-1].Position
+		}
+
+		for _, path := range paths {
+			last++
+			err := q.AddPlaylistItem(ctx, sqlc.AddPlaylistItemParams{Playlist: name, Position: last, Path: path})
+			if err != nil {
+				return fmt.Errorf("error adding playlist item: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveFromPlaylist removes paths from a playlist. It fails without
+// removing anything when one of them is not in it.
+func RemoveFromPlaylist(name string, paths []string) error {
+	return inTx(func(ctx context.Context, q *sqlc.Queries) error {
+		if err := playlistExists(ctx, q, name); err != nil {
+			return err
+		}
+		for _, path := range paths {
+			removed, err := q.RemovePlaylistItem(ctx, sqlc.RemovePlaylistItemParams{Playlist: name, Path: path})
+			if err != nil {
+				return fmt.Errorf("error removing playlist item: %w", err)
+			}
+			if removed == 0 {
+				return fmt.Errorf("%s is not in playlist %s", path, name)
+			}
+		}
+		return nil
+	})
+}
+
+// SetPlaylistPosition records the position of the item of a playlist shown
+// last.
+func SetPlaylistPosition(name string, position int64) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.SetPlaylistPosition(context.Background(), sqlc.SetPlaylistPositionParams{Position: position, Name: name})
+	if err != nil {
+		return fmt.Errorf("error setting playlist position: %w", err)
+	}
+	return nil
+}
+
+// GetPlaylistCycle returns the random cycle state of a playlist.
+func GetPlaylistCycle(name string) (shuffled []string, index int, err error) {
+	playlist, found, err := GetPlaylist(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !found {
+		return nil, 0, fmt.Errorf("unknown playlist: %s", name)
+	}
+
+	err = json.Unmarshal([]byte(playlist.ShuffledWallpapers), &shuffled)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error unmarshaling shuffled wallpapers: %w", err)
+	}
+	return shuffled, int(playlist.CurrentIndex), nil
+}
+
+// UpsertPlaylistCycle updates the random cycle state of a playlist.
+func UpsertPlaylistCycle(name string, shuffled []string, index int) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	data, err := json.Marshal(shuffled)
+	if err != nil {
+		return fmt.Errorf("error marshaling shuffled wallpapers: %w", err)
+	}
+
+	err = q.SetPlaylistCycle(context.Background(), sqlc.SetPlaylistCycleParams{
+		ShuffledWallpapers: string(data),
+		CurrentIndex:       int64(index),
+		Name:               name,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating playlist cycle: %w", err)
+	}
+	return nil
+}
+
+// SetWorkspaceWallpaper maps a Hyprland workspace to a wallpaper.
+func SetWorkspaceWallpaper(workspace, path string) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	err = q.UpsertWorkspaceWallpaper(context.Background(), sqlc.UpsertWorkspaceWallpaperParams{Workspace: workspace, Path: path})
+	if err != nil {
+		return fmt.Errorf("error setting workspace wallpaper: %w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceWallpaper returns the wallpaper of a workspace, reporting
+// whether it has one.
+func GetWorkspaceWallpaper(workspace string) (string, bool, error) {
+	q, err := Get()
+	if err != nil {
+		return "", false, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	path, err := q.GetWorkspaceWallpaper(context.Background(), workspace)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error getting workspace wallpaper: %w", err)
+	}
+	return path, true, nil
+}
+
+// GetWorkspaceWallpapers returns the wallpaper of every mapped workspace.
+func GetWorkspaceWallpapers() (map[string]string, error) {
+	q, err := Get()
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	rows, err := q.ListWorkspaceWallpapers(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspace wallpapers: %w", err)
+	}
+
+	wallpapers := make(map[string]string, len(rows))
+	for _, row := range rows {
+		wallpapers[row.Workspace] = row.Path
+	}
+	return wallpapers, nil
+}
+
+// RemoveWorkspaceWallpaper unmaps a workspace.
+func RemoveWorkspaceWallpaper(workspace string) error {
+	q, err := Get()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	removed, err := q.DeleteWorkspaceWallpaper(context.Background(), workspace)
+	if err != nil {
+		return fmt.Errorf("error removing workspace wallpaper: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("workspace %s has no wallpaper", workspace)
+	}
+	return nil
+}
+
+// playlistExists fails when there is no playlist called name.
+func playlistExists(ctx context.Context, q *sqlc.Queries, name string) error {
+	_, err := q.GetPlaylist(ctx, name)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("unknown playlist: %s", name)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting playlist: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds.
+func inTx(fn func(context.Context, *sqlc.Queries) error) error {
+	database, err := open()
+	if err != nil {
+		return fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	ctx := context.Background()
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	// Rolling back after Commit is a no-op.
+	defer tx.Rollback()
+
+	err = fn(ctx, sqlc.New(tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
@@ -1,33 +1,47 @@
 -- name: InsertWallpaperHistory :exec
 INSERT INTO
-    wallpaper_history (path, set_at)
+    wallpaper_history (path, monitor, fit, set_at)
 VALUES
-    (?, ?);
+    (?, ?, ?, ?);
 
 -- name: UpdateCurrentWallpaper :one
 INSERT
-    OR REPLACE INTO current_wallpaper (id, path, set_at)
+    OR REPLACE INTO current_wallpaper (monitor, path, set_at)
 VALUES
-    (1, ?, ?)
+    (?, ?, ?)
 RETURNING
+    monitor,
     path,
     set_at;
 
+-- name: ClearMonitorWallpapers :exec
+DELETE FROM
+    current_wallpaper
+WHERE
+    monitor != '';
+
 -- name: GetCurrentWallpaper :one
 SELECT
+    monitor,
     path,
     set_at
 FROM
     current_wallpaper
 WHERE
-    id = 1;
+    monitor IN (sqlc.arg(monitor), '')
+ORDER BY
+    monitor DESC
+LIMIT
+    1;
 
 -- name: GetWallpaperHistory :many
 SELECT
     id,
     path,
     set_at,
-    unset_at
+    unset_at,
+    monitor,
+    fit
 FROM
     wallpaper_history
 WHERE
@@ -39,31 +53,72 @@ WHERE
         ? IS NULL
         OR id <= ?
     )
+    AND (
+        monitor IN (sqlc.arg(monitor), '')
+        OR sqlc.arg(monitor) = ''
+    )
 ORDER BY
     set_at DESC
 LIMIT
-    ?;
+    sqlc.arg(max_entries);
 
 -- name: GetPreviousWallpaper :one
 SELECT
     id,
     path,
-    set_at
+    set_at,
+    unset_at,
+    monitor,
+    fit
 FROM
     wallpaper_history
 WHERE
-    set_at < (
+    wallpaper_history.monitor IN (sqlc.arg(monitor), '')
+    AND set_at < (
         SELECT
             set_at
         FROM
             current_wallpaper
         WHERE
-            id = 1
+            current_wallpaper.monitor IN (sqlc.arg(monitor), '')
+        ORDER BY
+            current_wallpaper.monitor DESC
+        LIMIT
+            1
     )
 ORDER BY
     set_at DESC
 LIMIT
-    1;
+    1 OFFSET sqlc.arg(skip);
+
+-- name: GetNextWallpaper :one
+SELECT
+    id,
+    path,
+    set_at,
+    unset_at,
+    monitor,
+    fit
+FROM
+    wallpaper_history
+WHERE
+    wallpaper_history.monitor IN (sqlc.arg(monitor), '')
+    AND set_at > (
+        SELECT
+            set_at
+        FROM
+            current_wallpaper
+        WHERE
+            current_wallpaper.monitor IN (sqlc.arg(monitor), '')
+        ORDER BY
+            current_wallpaper.monitor DESC
+        LIMIT
+            1
+    )
+ORDER BY
+    set_at ASC
+LIMIT
+    1 OFFSET sqlc.arg(skip);
 
 -- name: MarkWallpaperUnset :exec
 UPDATE
@@ -71,11 +126,372 @@ UPDATE
 SET
     unset_at = ?
 WHERE
-    path = ?
-    AND unset_at IS NULL;
+    unset_at IS NULL
+    AND (
+        monitor IN (sqlc.arg(monitor), '')
+        OR sqlc.arg(monitor) = ''
+    );
 
 -- name: GetRandomCycle :one
 SELECT shuffled_wallpapers, current_index FROM random_cycle WHERE id = 1;
 
 -- name: UpsertRandomCycle :exec
 INSERT OR REPLACE INTO random_cycle (id, shuffled_wallpapers, current_index) VALUES (1, ?, ?);
+
+-- name: SetWallpaperFavorite :exec
+INSERT INTO
+    wallpaper_flags (path, favorite)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    favorite = excluded.favorite;
+
+-- name: SetWallpaperBanned :exec
+INSERT INTO
+    wallpaper_flags (path, banned)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    banned = excluded.banned;
+
+-- name: SetWallpaperRating :exec
+INSERT INTO
+    wallpaper_flags (path, rating)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    rating = excluded.rating;
+
+-- name: DeleteUnflaggedWallpapers :exec
+DELETE FROM
+    wallpaper_flags
+WHERE
+    NOT favorite
+    AND NOT banned
+    AND rating = 0;
+
+-- name: ListWallpaperFlags :many
+SELECT
+    path,
+    favorite,
+    banned,
+    rating
+FROM
+    wallpaper_flags;
+
+-- name: AddWallpaperTag :exec
+INSERT
+    OR IGNORE INTO wallpaper_tags (path, tag)
+VALUES
+    (?, ?);
+
+-- name: RemoveWallpaperTag :exec
+DELETE FROM
+    wallpaper_tags
+WHERE
+    path = ?
+    AND tag = ?;
+
+-- name: ListWallpaperTags :many
+SELECT
+    path,
+    tag
+FROM
+    wallpaper_tags
+ORDER BY
+    path,
+    tag;
+
+-- name: ListLastShown :many
+SELECT
+    path,
+    set_at
+FROM
+    wallpaper_history
+WHERE
+    id IN (
+        SELECT
+            MAX(id)
+        FROM
+            wallpaper_history
+        GROUP BY
+            path
+    );
+
+-- name: ListLibrary :many
+SELECT
+    root,
+    path,
+    size,
+    mtime,
+    width,
+    height,
+    format
+FROM
+    wallpapers
+WHERE
+    root = ?
+ORDER BY
+    path;
+
+-- name: UpsertLibraryWallpaper :exec
+INSERT
+    OR REPLACE INTO wallpapers (root, path, size, mtime, width, height, format)
+VALUES
+    (?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteLibraryWallpaper :exec
+DELETE FROM
+    wallpapers
+WHERE
+    root = ?
+    AND path = ?;
+
+-- name: GetLibraryScan :one
+SELECT
+    root,
+    recursive,
+    scanned_at
+FROM
+    library_scans
+WHERE
+    root = ?;
+
+-- name: UpsertLibraryScan :exec
+INSERT
+    OR REPLACE INTO library_scans (root, recursive, scanned_at)
+VALUES
+    (?, ?, ?);
+
+-- name: RenameHistoryPath :exec
+UPDATE
+    wallpaper_history
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: RenameCurrentPath :exec
+UPDATE
+    current_wallpaper
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: RenameFlagsPath :exec
+UPDATE
+    OR REPLACE wallpaper_flags
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: RenameTagsPath :exec
+UPDATE
+    OR IGNORE wallpaper_tags
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: DeleteHistoryPath :exec
+DELETE FROM
+    wallpaper_history
+WHERE
+    path = ?;
+
+-- name: DeleteCurrentPath :exec
+DELETE FROM
+    current_wallpaper
+WHERE
+    path = ?;
+
+-- name: DeleteTagsPath :exec
+DELETE FROM
+    wallpaper_tags
+WHERE
+    path = ?;
+
+-- name: GetPalette :one
+SELECT
+    path,
+    size,
+    mtime,
+    count,
+    colors
+FROM
+    palettes
+WHERE
+    path = ?;
+
+-- name: UpsertPalette :exec
+INSERT
+    OR REPLACE INTO palettes (path, size, mtime, count, colors)
+VALUES
+    (?, ?, ?, ?, ?);
+
+-- name: RenamePalettePath :exec
+UPDATE
+    OR REPLACE palettes
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: CreatePlaylist :exec
+INSERT INTO
+    playlists (name)
+VALUES
+    (?);
+
+-- name: GetPlaylist :one
+SELECT
+    name,
+    position,
+    shuffled_wallpapers,
+    current_index,
+    active
+FROM
+    playlists
+WHERE
+    name = ?;
+
+-- name: ListPlaylists :many
+SELECT
+    name,
+    position,
+    shuffled_wallpapers,
+    current_index,
+    active
+FROM
+    playlists
+ORDER BY
+    name;
+
+-- name: SetActivePlaylist :exec
+UPDATE
+    playlists
+SET
+    active = name = sqlc.arg(name);
+
+-- name: SetPlaylistPosition :exec
+UPDATE
+    playlists
+SET
+    position = ?
+WHERE
+    name = ?;
+
+-- name: SetPlaylistCycle :exec
+UPDATE
+    playlists
+SET
+    shuffled_wallpapers = ?,
+    current_index = ?
+WHERE
+    name = ?;
+
+-- name: ListPlaylistItems :many
+SELECT
+    playlist,
+    position,
+    path
+FROM
+    playlist_items
+WHERE
+    playlist = ?
+ORDER BY
+    position;
+
+-- name: AddPlaylistItem :exec
+INSERT
+    OR IGNORE INTO playlist_items (playlist, position, path)
+VALUES
+    (?, ?, ?);
+
+-- name: RemovePlaylistItem :execrows
+DELETE FROM
+    playlist_items
+WHERE
+    playlist = ?
+    AND path = ?;
+
+-- name: RenamePlaylistItemsPath :exec
+UPDATE
+    OR IGNORE playlist_items
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: DeletePlaylistItemsPath :exec
+DELETE FROM
+    playlist_items
+WHERE
+    path = ?;
+
+-- name: UpsertWorkspaceWallpaper :exec
+INSERT
+    OR REPLACE INTO workspace_wallpapers (workspace, path)
+VALUES
+    (?, ?);
+
+-- name: GetWorkspaceWallpaper :one
+SELECT
+    path
+FROM
+    workspace_wallpapers
+WHERE
+    workspace = ?;
+
+-- name: ListWorkspaceWallpapers :many
+SELECT
+    workspace,
+    path
+FROM
+    workspace_wallpapers
+ORDER BY
+    workspace;
+
+-- name: DeleteWorkspaceWallpaper :execrows
+DELETE FROM
+    workspace_wallpapers
+WHERE
+    workspace = ?;
+
+-- name: RenameWorkspacePath :exec
+UPDATE
+    workspace_wallpapers
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
+
+-- name: GetFileHash :one
+SELECT
+    path,
+    size,
+    mtime,
+    hash
+FROM
+    file_hashes
+WHERE
+    path = ?;
+
+-- name: UpsertFileHash :exec
+INSERT
+    OR REPLACE INTO file_hashes (path, size, mtime, hash)
+VALUES
+    (?, ?, ?, ?);
+
+-- name: RenameFileHashPath :exec
+UPDATE
+    OR REPLACE file_hashes
+SET
+    path = sqlc.arg(new_path)
+WHERE
+    path = sqlc.arg(old_path);
\ No newline at end of file
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestSetWallpaperClosesTheSharedEntry(t *testing.T) {
+	newHome(t)
+
+	for _, set := range []struct{ path, monitor string }{
+		{"shared.png", ""},
+		{"left.png", "DP-1"},
+		{"right.png", "HDMI-A-1"},
+	} {
+		if err := SetWallpaper(set.path, set.monitor, ""); err != nil {
+			t.Fatalf("SetWallpaper(%s, %q) failed: %v", set.path, set.monitor, err)
+		}
+	}
+
+	history, err := GetWallpaperHistory("", 10)
+	if err != nil {
+		t.Fatalf("GetWallpaperHistory() failed: %v", err)
+	}
+	shown := make(map[string]bool)
+	for _, entry := range history {
+		shown[entry.Path] = entry.UnsetAt == nil
+	}
+	want := map[string]bool{"shared.png": false, "left.png": true, "right.png": true}
+	for path, wantOpen := range want {
+		if shown[path] != wantOpen {
+			t.Errorf("%s still shown = %v, want %v", path, shown[path], wantOpen)
+		}
+	}
+}
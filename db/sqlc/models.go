@@ -9,9 +9,44 @@ import (
 )
 
 type CurrentWallpaper struct {
-	ID    int64
+	Monitor string
+	Path    string
+	SetAt   time.Time
+}
+
+type FileHash struct {
 	Path  string
-	SetAt time.Time
+	Size  int64
+	Mtime time.Time
+	Hash  string
+}
+
+type LibraryScan struct {
+	Root      string
+	Recursive bool
+	ScannedAt time.Time
+}
+
+type Palette struct {
+	Path   string
+	Size   int64
+	Mtime  time.Time
+	Count  int64
+	Colors string
+}
+
+type Playlist struct {
+	Name               string
+	Position           int64
+	ShuffledWallpapers string
+	CurrentIndex       int64
+	Active             bool
+}
+
+type PlaylistItem struct {
+	Playlist string
+	Position int64
+	Path     string
 }
 
 type RandomCycle struct {
@@ -20,9 +55,38 @@ type RandomCycle struct {
 	CurrentIndex       int64
 }
 
+type Wallpaper struct {
+	Root   string
+	Path   string
+	Size   int64
+	Mtime  time.Time
+	Width  int64
+	Height int64
+	Format string
+}
+
+type WallpaperFlag struct {
+	Path     string
+	Favorite bool
+	Banned   bool
+	Rating   int64
+}
+
 type WallpaperHistory struct {
 	ID      int64
 	Path    string
 	SetAt   time.Time
 	UnsetAt *time.Time
+	Monitor string
+	Fit     string
+}
+
+type WallpaperTag struct {
+	Path string
+	Tag  string
+}
+
+type WorkspaceWallpaper struct {
+	Workspace string
+	Path      string
 }
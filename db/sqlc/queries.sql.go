@@ -10,25 +10,322 @@ import (
 	"time"
 )
 
+const addPlaylistItem = `-- name: AddPlaylistItem :exec
+INSERT
+    OR IGNORE INTO playlist_items (playlist, position, path)
+VALUES
+    (?, ?, ?)
+`
+
+type AddPlaylistItemParams struct {
+	Playlist string
+	Position int64
+	Path     string
+}
+
+func (q *Queries) AddPlaylistItem(ctx context.Context, arg AddPlaylistItemParams) error {
+	_, err := q.db.ExecContext(ctx, addPlaylistItem, arg.Playlist, arg.Position, arg.Path)
+	return err
+}
+
+const addWallpaperTag = `-- name: AddWallpaperTag :exec
+INSERT
+    OR IGNORE INTO wallpaper_tags (path, tag)
+VALUES
+    (?, ?)
+`
+
+type AddWallpaperTagParams struct {
+	Path string
+	Tag  string
+}
+
+func (q *Queries) AddWallpaperTag(ctx context.Context, arg AddWallpaperTagParams) error {
+	_, err := q.db.ExecContext(ctx, addWallpaperTag, arg.Path, arg.Tag)
+	return err
+}
+
+const clearMonitorWallpapers = `-- name: ClearMonitorWallpapers :exec
+DELETE FROM
+    current_wallpaper
+WHERE
+    monitor != ''
+`
+
+func (q *Queries) ClearMonitorWallpapers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearMonitorWallpapers)
+	return err
+}
+
+const createPlaylist = `-- name: CreatePlaylist :exec
+INSERT INTO
+    playlists (name)
+VALUES
+    (?)
+`
+
+func (q *Queries) CreatePlaylist(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, createPlaylist, name)
+	return err
+}
+
+const deleteCurrentPath = `-- name: DeleteCurrentPath :exec
+DELETE FROM
+    current_wallpaper
+WHERE
+    path = ?
+`
+
+func (q *Queries) DeleteCurrentPath(ctx context.Context, path string) error {
+	_, err := q.db.ExecContext(ctx, deleteCurrentPath, path)
+	return err
+}
+
+const deleteHistoryPath = `-- name: DeleteHistoryPath :exec
+DELETE FROM
+    wallpaper_history
+WHERE
+    path = ?
+`
+
+func (q *Queries) DeleteHistoryPath(ctx context.Context, path string) error {
+	_, err := q.db.ExecContext(ctx, deleteHistoryPath, path)
+	return err
+}
+
+const deleteLibraryWallpaper = `-- name: DeleteLibraryWallpaper :exec
+DELETE FROM
+    wallpapers
+WHERE
+    root = ?
+    AND path = ?
+`
+
+type DeleteLibraryWallpaperParams struct {
+	Root string
+	Path string
+}
+
+func (q *Queries) DeleteLibraryWallpaper(ctx context.Context, arg DeleteLibraryWallpaperParams) error {
+	_, err := q.db.ExecContext(ctx, deleteLibraryWallpaper, arg.Root, arg.Path)
+	return err
+}
+
+const deletePlaylistItemsPath = `-- name: DeletePlaylistItemsPath :exec
+DELETE FROM
+    playlist_items
+WHERE
+    path = ?
+`
+
+func (q *Queries) DeletePlaylistItemsPath(ctx context.Context, path string) error {
+	_, err := q.db.ExecContext(ctx, deletePlaylistItemsPath, path)
+	return err
+}
+
+const deleteTagsPath = `-- name: DeleteTagsPath :exec
+DELETE FROM
+    wallpaper_tags
+WHERE
+    path = ?
+`
+
+func (q *Queries) DeleteTagsPath(ctx context.Context, path string) error {
+	_, err := q.db.ExecContext(ctx, deleteTagsPath, path)
+	return err
+}
+
+const deleteUnflaggedWallpapers = `-- name: DeleteUnflaggedWallpapers :exec
+DELETE FROM
+    wallpaper_flags
+WHERE
+    NOT favorite
+    AND NOT banned
+    AND rating = 0
+`
+
+func (q *Queries) DeleteUnflaggedWallpapers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteUnflaggedWallpapers)
+	return err
+}
+
+const deleteWorkspaceWallpaper = `-- name: DeleteWorkspaceWallpaper :execrows
+DELETE FROM
+    workspace_wallpapers
+WHERE
+    workspace = ?
+`
+
+func (q *Queries) DeleteWorkspaceWallpaper(ctx context.Context, workspace string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWorkspaceWallpaper, workspace)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getCurrentWallpaper = `-- name: GetCurrentWallpaper :one
 SELECT
+    monitor,
     path,
     set_at
 FROM
     current_wallpaper
 WHERE
-    id = 1
+    monitor IN (?1, '')
+ORDER BY
+    monitor DESC
+LIMIT
+    1
 `
 
-type GetCurrentWallpaperRow struct {
-	Path  string
-	SetAt time.Time
+func (q *Queries) GetCurrentWallpaper(ctx context.Context, monitor string) (CurrentWallpaper, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentWallpaper, monitor)
+	var i CurrentWallpaper
+	err := row.Scan(&i.Monitor, &i.Path, &i.SetAt)
+	return i, err
+}
+
+const getFileHash = `-- name: GetFileHash :one
+SELECT
+    path,
+    size,
+    mtime,
+    hash
+FROM
+    file_hashes
+WHERE
+    path = ?
+`
+
+func (q *Queries) GetFileHash(ctx context.Context, path string) (FileHash, error) {
+	row := q.db.QueryRowContext(ctx, getFileHash, path)
+	var i FileHash
+	err := row.Scan(
+		&i.Path,
+		&i.Size,
+		&i.Mtime,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getLibraryScan = `-- name: GetLibraryScan :one
+SELECT
+    root,
+    recursive,
+    scanned_at
+FROM
+    library_scans
+WHERE
+    root = ?
+`
+
+func (q *Queries) GetLibraryScan(ctx context.Context, root string) (LibraryScan, error) {
+	row := q.db.QueryRowContext(ctx, getLibraryScan, root)
+	var i LibraryScan
+	err := row.Scan(&i.Root, &i.Recursive, &i.ScannedAt)
+	return i, err
+}
+
+const getNextWallpaper = `-- name: GetNextWallpaper :one
+SELECT
+    id,
+    path,
+    set_at,
+    unset_at,
+    monitor,
+    fit
+FROM
+    wallpaper_history
+WHERE
+    wallpaper_history.monitor IN (?1, '')
+    AND set_at > (
+        SELECT
+            set_at
+        FROM
+            current_wallpaper
+        WHERE
+            current_wallpaper.monitor IN (?1, '')
+        ORDER BY
+            current_wallpaper.monitor DESC
+        LIMIT
+            1
+    )
+ORDER BY
+    set_at ASC
+LIMIT
+    1 OFFSET ?2
+`
+
+type GetNextWallpaperParams struct {
+	Monitor string
+	Skip    int64
 }
 
-func (q *Queries) GetCurrentWallpaper(ctx context.Context) (GetCurrentWallpaperRow, error) {
-	row := q.db.QueryRowContext(ctx, getCurrentWallpaper)
-	var i GetCurrentWallpaperRow
-	err := row.Scan(&i.Path, &i.SetAt)
+func (q *Queries) GetNextWallpaper(ctx context.Context, arg GetNextWallpaperParams) (WallpaperHistory, error) {
+	row := q.db.QueryRowContext(ctx, getNextWallpaper, arg.Monitor, arg.Skip)
+	var i WallpaperHistory
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.SetAt,
+		&i.UnsetAt,
+		&i.Monitor,
+		&i.Fit,
+	)
+	return i, err
+}
+
+const getPalette = `-- name: GetPalette :one
+SELECT
+    path,
+    size,
+    mtime,
+    count,
+    colors
+FROM
+    palettes
+WHERE
+    path = ?
+`
+
+func (q *Queries) GetPalette(ctx context.Context, path string) (Palette, error) {
+	row := q.db.QueryRowContext(ctx, getPalette, path)
+	var i Palette
+	err := row.Scan(
+		&i.Path,
+		&i.Size,
+		&i.Mtime,
+		&i.Count,
+		&i.Colors,
+	)
+	return i, err
+}
+
+const getPlaylist = `-- name: GetPlaylist :one
+SELECT
+    name,
+    position,
+    shuffled_wallpapers,
+    current_index,
+    active
+FROM
+    playlists
+WHERE
+    name = ?
+`
+
+func (q *Queries) GetPlaylist(ctx context.Context, name string) (Playlist, error) {
+	row := q.db.QueryRowContext(ctx, getPlaylist, name)
+	var i Playlist
+	err := row.Scan(
+		&i.Name,
+		&i.Position,
+		&i.ShuffledWallpapers,
+		&i.CurrentIndex,
+		&i.Active,
+	)
 	return i, err
 }
 
@@ -36,34 +333,48 @@ const getPreviousWallpaper = `-- name: GetPreviousWallpaper :one
 SELECT
     id,
     path,
-    set_at
+    set_at,
+    unset_at,
+    monitor,
+    fit
 FROM
     wallpaper_history
 WHERE
-    set_at < (
+    wallpaper_history.monitor IN (?1, '')
+    AND set_at < (
         SELECT
             set_at
         FROM
             current_wallpaper
         WHERE
-            id = 1
+            current_wallpaper.monitor IN (?1, '')
+        ORDER BY
+            current_wallpaper.monitor DESC
+        LIMIT
+            1
     )
 ORDER BY
     set_at DESC
 LIMIT
-    1
+    1 OFFSET ?2
 `
 
-type GetPreviousWallpaperRow struct {
-	ID    int64
-	Path  string
-	SetAt time.Time
+type GetPreviousWallpaperParams struct {
+	Monitor string
+	Skip    int64
 }
 
-func (q *Queries) GetPreviousWallpaper(ctx context.Context) (GetPreviousWallpaperRow, error) {
-	row := q.db.QueryRowContext(ctx, getPreviousWallpaper)
-	var i GetPreviousWallpaperRow
-	err := row.Scan(&i.ID, &i.Path, &i.SetAt)
+func (q *Queries) GetPreviousWallpaper(ctx context.Context, arg GetPreviousWallpaperParams) (WallpaperHistory, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousWallpaper, arg.Monitor, arg.Skip)
+	var i WallpaperHistory
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.SetAt,
+		&i.UnsetAt,
+		&i.Monitor,
+		&i.Fit,
+	)
 	return i, err
 }
 
@@ -88,7 +399,9 @@ SELECT
     id,
     path,
     set_at,
-    unset_at
+    unset_at,
+    monitor,
+    fit
 FROM
     wallpaper_history
 WHERE
@@ -100,18 +413,23 @@ WHERE
         ? IS NULL
         OR id <= ?
     )
+    AND (
+        monitor IN (?5, '')
+        OR ?5 = ''
+    )
 ORDER BY
     set_at DESC
 LIMIT
-    ?
+    ?6
 `
 
 type GetWallpaperHistoryParams struct {
-	Column1 interface{}
-	SetAt   time.Time
-	Column3 interface{}
-	ID      int64
-	Limit   int64
+	Column1    interface{}
+	SetAt      time.Time
+	Column3    interface{}
+	ID         int64
+	Monitor    string
+	MaxEntries int64
 }
 
 func (q *Queries) GetWallpaperHistory(ctx context.Context, arg GetWallpaperHistoryParams) ([]WallpaperHistory, error) {
@@ -120,7 +438,8 @@ func (q *Queries) GetWallpaperHistory(ctx context.Context, arg GetWallpaperHisto
 		arg.SetAt,
 		arg.Column3,
 		arg.ID,
-		arg.Limit,
+		arg.Monitor,
+		arg.MaxEntries,
 	)
 	if err != nil {
 		return nil, err
@@ -134,6 +453,8 @@ func (q *Queries) GetWallpaperHistory(ctx context.Context, arg GetWallpaperHisto
 			&i.Path,
 			&i.SetAt,
 			&i.UnsetAt,
+			&i.Monitor,
+			&i.Fit,
 		); err != nil {
 			return nil, err
 		}
@@ -148,80 +469,797 @@ func (q *Queries) GetWallpaperHistory(ctx context.Context, arg GetWallpaperHisto
 	return items, nil
 }
 
+const getWorkspaceWallpaper = `-- name: GetWorkspaceWallpaper :one
+SELECT
+    path
+FROM
+    workspace_wallpapers
+WHERE
+    workspace = ?
+`
+
+func (q *Queries) GetWorkspaceWallpaper(ctx context.Context, workspace string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceWallpaper, workspace)
+	var path string
+	err := row.Scan(&path)
+	return path, err
+}
+
 const insertWallpaperHistory = `-- name: InsertWallpaperHistory :exec
 INSERT INTO
-    wallpaper_history (path, set_at)
+    wallpaper_history (path, monitor, fit, set_at)
 VALUES
-    (?, ?)
+    (?, ?, ?, ?)
 `
 
 type InsertWallpaperHistoryParams struct {
-	Path  string
-	SetAt time.Time
+	Path    string
+	Monitor string
+	Fit     string
+	SetAt   time.Time
 }
 
 func (q *Queries) InsertWallpaperHistory(ctx context.Context, arg InsertWallpaperHistoryParams) error {
-	_, err := q.db.ExecContext(ctx, insertWallpaperHistory, arg.Path, arg.SetAt)
+	_, err := q.db.ExecContext(ctx, insertWallpaperHistory,
+		arg.Path,
+		arg.Monitor,
+		arg.Fit,
+		arg.SetAt,
+	)
 	return err
 }
 
-const markWallpaperUnset = `-- name: MarkWallpaperUnset :exec
-UPDATE
+const listLastShown = `-- name: ListLastShown :many
+SELECT
+    path,
+    set_at
+FROM
     wallpaper_history
-SET
-    unset_at = ?
 WHERE
-    path = ?
-    AND unset_at IS NULL
+    id IN (
+        SELECT
+            MAX(id)
+        FROM
+            wallpaper_history
+        GROUP BY
+            path
+    )
 `
 
-type MarkWallpaperUnsetParams struct {
-	UnsetAt *time.Time
-	Path    string
+type ListLastShownRow struct {
+	Path  string
+	SetAt time.Time
 }
 
-func (q *Queries) MarkWallpaperUnset(ctx context.Context, arg MarkWallpaperUnsetParams) error {
-	_, err := q.db.ExecContext(ctx, markWallpaperUnset, arg.UnsetAt, arg.Path)
-	return err
+func (q *Queries) ListLastShown(ctx context.Context) ([]ListLastShownRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLastShown)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLastShownRow
+	for rows.Next() {
+		var i ListLastShownRow
+		if err := rows.Scan(&i.Path, &i.SetAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const updateCurrentWallpaper = `-- name: UpdateCurrentWallpaper :one
-INSERT
-    OR REPLACE INTO current_wallpaper (id, path, set_at)
-VALUES
-    (1, ?, ?)
-RETURNING
+const listLibrary = `-- name: ListLibrary :many
+SELECT
+    root,
     path,
-    set_at
+    size,
+    mtime,
+    width,
+    height,
+    format
+FROM
+    wallpapers
+WHERE
+    root = ?
+ORDER BY
+    path
 `
 
-type UpdateCurrentWallpaperParams struct {
-	Path  string
-	SetAt time.Time
-}
-
-type UpdateCurrentWallpaperRow struct {
-	Path  string
-	SetAt time.Time
-}
-
-func (q *Queries) UpdateCurrentWallpaper(ctx context.Context, arg UpdateCurrentWallpaperParams) (UpdateCurrentWallpaperRow, error) {
-	row := q.db.QueryRowContext(ctx, updateCurrentWallpaper, arg.Path, arg.SetAt)
-	var i UpdateCurrentWallpaperRow
-	err := row.Scan(&i.Path, &i.SetAt)
-	return i, err
+func (q *Queries) ListLibrary(ctx context.Context, root string) ([]Wallpaper, error) {
+	rows, err := q.db.QueryContext(ctx, listLibrary, root)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Wallpaper
+	for rows.Next() {
+		var i Wallpaper
+		if err := rows.Scan(
+			&i.Root,
+			&i.Path,
+			&i.Size,
+			&i.Mtime,
+			&i.Width,
+			&i.Height,
+			&i.Format,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const upsertRandomCycle = `-- name: UpsertRandomCycle :exec
-INSERT OR REPLACE INTO random_cycle (id, shuffled_wallpapers, current_index) VALUES (1, ?, ?)
+const listPlaylistItems = `-- name: ListPlaylistItems :many
+SELECT
+    playlist,
+    position,
+    path
+FROM
+    playlist_items
+WHERE
+    playlist = ?
+ORDER BY
+    position
 `
 
-type UpsertRandomCycleParams struct {
-	ShuffledWallpapers string
-	CurrentIndex       int64
-}
-
+func (q *Queries) ListPlaylistItems(ctx context.Context, playlist string) ([]PlaylistItem, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistItems, playlist)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlaylistItem
+	for rows.Next() {
+		var i PlaylistItem
+		if err := rows.Scan(&i.Playlist, &i.Position, &i.Path); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylists = `-- name: ListPlaylists :many
+SELECT
+    name,
+    position,
+    shuffled_wallpapers,
+    current_index,
+    active
+FROM
+    playlists
+ORDER BY
+    name
+`
+
+func (q *Queries) ListPlaylists(ctx context.Context) ([]Playlist, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Playlist
+	for rows.Next() {
+		var i Playlist
+		if err := rows.Scan(
+			&i.Name,
+			&i.Position,
+			&i.ShuffledWallpapers,
+			&i.CurrentIndex,
+			&i.Active,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWallpaperFlags = `-- name: ListWallpaperFlags :many
+SELECT
+    path,
+    favorite,
+    banned,
+    rating
+FROM
+    wallpaper_flags
+`
+
+func (q *Queries) ListWallpaperFlags(ctx context.Context) ([]WallpaperFlag, error) {
+	rows, err := q.db.QueryContext(ctx, listWallpaperFlags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WallpaperFlag
+	for rows.Next() {
+		var i WallpaperFlag
+		if err := rows.Scan(
+			&i.Path,
+			&i.Favorite,
+			&i.Banned,
+			&i.Rating,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWallpaperTags = `-- name: ListWallpaperTags :many
+SELECT
+    path,
+    tag
+FROM
+    wallpaper_tags
+ORDER BY
+    path,
+    tag
+`
+
+func (q *Queries) ListWallpaperTags(ctx context.Context) ([]WallpaperTag, error) {
+	rows, err := q.db.QueryContext(ctx, listWallpaperTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WallpaperTag
+	for rows.Next() {
+		var i WallpaperTag
+		if err := rows.Scan(&i.Path, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkspaceWallpapers = `-- name: ListWorkspaceWallpapers :many
+SELECT
+    workspace,
+    path
+FROM
+    workspace_wallpapers
+ORDER BY
+    workspace
+`
+
+func (q *Queries) ListWorkspaceWallpapers(ctx context.Context) ([]WorkspaceWallpaper, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkspaceWallpapers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceWallpaper
+	for rows.Next() {
+		var i WorkspaceWallpaper
+		if err := rows.Scan(&i.Workspace, &i.Path); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markWallpaperUnset = `-- name: MarkWallpaperUnset :exec
+UPDATE
+    wallpaper_history
+SET
+    unset_at = ?
+WHERE
+    unset_at IS NULL
+    AND (
+        monitor IN (?2, '')
+        OR ?2 = ''
+    )
+`
+
+type MarkWallpaperUnsetParams struct {
+	UnsetAt *time.Time
+	Monitor string
+}
+
+func (q *Queries) MarkWallpaperUnset(ctx context.Context, arg MarkWallpaperUnsetParams) error {
+	_, err := q.db.ExecContext(ctx, markWallpaperUnset, arg.UnsetAt, arg.Monitor)
+	return err
+}
+
+const removePlaylistItem = `-- name: RemovePlaylistItem :execrows
+DELETE FROM
+    playlist_items
+WHERE
+    playlist = ?
+    AND path = ?
+`
+
+type RemovePlaylistItemParams struct {
+	Playlist string
+	Path     string
+}
+
+func (q *Queries) RemovePlaylistItem(ctx context.Context, arg RemovePlaylistItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removePlaylistItem, arg.Playlist, arg.Path)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeWallpaperTag = `-- name: RemoveWallpaperTag :exec
+DELETE FROM
+    wallpaper_tags
+WHERE
+    path = ?
+    AND tag = ?
+`
+
+type RemoveWallpaperTagParams struct {
+	Path string
+	Tag  string
+}
+
+func (q *Queries) RemoveWallpaperTag(ctx context.Context, arg RemoveWallpaperTagParams) error {
+	_, err := q.db.ExecContext(ctx, removeWallpaperTag, arg.Path, arg.Tag)
+	return err
+}
+
+const renameCurrentPath = `-- name: RenameCurrentPath :exec
+UPDATE
+    current_wallpaper
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameCurrentPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameCurrentPath(ctx context.Context, arg RenameCurrentPathParams) error {
+	_, err := q.db.ExecContext(ctx, renameCurrentPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renameFileHashPath = `-- name: RenameFileHashPath :exec
+UPDATE
+    OR REPLACE file_hashes
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameFileHashPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameFileHashPath(ctx context.Context, arg RenameFileHashPathParams) error {
+	_, err := q.db.ExecContext(ctx, renameFileHashPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renameFlagsPath = `-- name: RenameFlagsPath :exec
+UPDATE
+    OR REPLACE wallpaper_flags
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameFlagsPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameFlagsPath(ctx context.Context, arg RenameFlagsPathParams) error {
+	_, err := q.db.ExecContext(ctx, renameFlagsPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renameHistoryPath = `-- name: RenameHistoryPath :exec
+UPDATE
+    wallpaper_history
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameHistoryPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameHistoryPath(ctx context.Context, arg RenameHistoryPathParams) error {
+	_, err := q.db.ExecContext(ctx, renameHistoryPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renamePalettePath = `-- name: RenamePalettePath :exec
+UPDATE
+    OR REPLACE palettes
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenamePalettePathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenamePalettePath(ctx context.Context, arg RenamePalettePathParams) error {
+	_, err := q.db.ExecContext(ctx, renamePalettePath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renamePlaylistItemsPath = `-- name: RenamePlaylistItemsPath :exec
+UPDATE
+    OR IGNORE playlist_items
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenamePlaylistItemsPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenamePlaylistItemsPath(ctx context.Context, arg RenamePlaylistItemsPathParams) error {
+	_, err := q.db.ExecContext(ctx, renamePlaylistItemsPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renameTagsPath = `-- name: RenameTagsPath :exec
+UPDATE
+    OR IGNORE wallpaper_tags
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameTagsPathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameTagsPath(ctx context.Context, arg RenameTagsPathParams) error {
+	_, err := q.db.ExecContext(ctx, renameTagsPath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const renameWorkspacePath = `-- name: RenameWorkspacePath :exec
+UPDATE
+    workspace_wallpapers
+SET
+    path = ?1
+WHERE
+    path = ?2
+`
+
+type RenameWorkspacePathParams struct {
+	NewPath string
+	OldPath string
+}
+
+func (q *Queries) RenameWorkspacePath(ctx context.Context, arg RenameWorkspacePathParams) error {
+	_, err := q.db.ExecContext(ctx, renameWorkspacePath, arg.NewPath, arg.OldPath)
+	return err
+}
+
+const setActivePlaylist = `-- name: SetActivePlaylist :exec
+UPDATE
+    playlists
+SET
+    active = name = ?1
+`
+
+func (q *Queries) SetActivePlaylist(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, setActivePlaylist, name)
+	return err
+}
+
+const setPlaylistCycle = `-- name: SetPlaylistCycle :exec
+UPDATE
+    playlists
+SET
+    shuffled_wallpapers = ?,
+    current_index = ?
+WHERE
+    name = ?
+`
+
+type SetPlaylistCycleParams struct {
+	ShuffledWallpapers string
+	CurrentIndex       int64
+	Name               string
+}
+
+func (q *Queries) SetPlaylistCycle(ctx context.Context, arg SetPlaylistCycleParams) error {
+	_, err := q.db.ExecContext(ctx, setPlaylistCycle, arg.ShuffledWallpapers, arg.CurrentIndex, arg.Name)
+	return err
+}
+
+const setPlaylistPosition = `-- name: SetPlaylistPosition :exec
+UPDATE
+    playlists
+SET
+    position = ?
+WHERE
+    name = ?
+`
+
+type SetPlaylistPositionParams struct {
+	Position int64
+	Name     string
+}
+
+func (q *Queries) SetPlaylistPosition(ctx context.Context, arg SetPlaylistPositionParams) error {
+	_, err := q.db.ExecContext(ctx, setPlaylistPosition, arg.Position, arg.Name)
+	return err
+}
+
+const setWallpaperBanned = `-- name: SetWallpaperBanned :exec
+INSERT INTO
+    wallpaper_flags (path, banned)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    banned = excluded.banned
+`
+
+type SetWallpaperBannedParams struct {
+	Path   string
+	Banned bool
+}
+
+func (q *Queries) SetWallpaperBanned(ctx context.Context, arg SetWallpaperBannedParams) error {
+	_, err := q.db.ExecContext(ctx, setWallpaperBanned, arg.Path, arg.Banned)
+	return err
+}
+
+const setWallpaperFavorite = `-- name: SetWallpaperFavorite :exec
+INSERT INTO
+    wallpaper_flags (path, favorite)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    favorite = excluded.favorite
+`
+
+type SetWallpaperFavoriteParams struct {
+	Path     string
+	Favorite bool
+}
+
+func (q *Queries) SetWallpaperFavorite(ctx context.Context, arg SetWallpaperFavoriteParams) error {
+	_, err := q.db.ExecContext(ctx, setWallpaperFavorite, arg.Path, arg.Favorite)
+	return err
+}
+
+const setWallpaperRating = `-- name: SetWallpaperRating :exec
+INSERT INTO
+    wallpaper_flags (path, rating)
+VALUES
+    (?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    rating = excluded.rating
+`
+
+type SetWallpaperRatingParams struct {
+	Path   string
+	Rating int64
+}
+
+func (q *Queries) SetWallpaperRating(ctx context.Context, arg SetWallpaperRatingParams) error {
+	_, err := q.db.ExecContext(ctx, setWallpaperRating, arg.Path, arg.Rating)
+	return err
+}
+
+const updateCurrentWallpaper = `-- name: UpdateCurrentWallpaper :one
+INSERT
+    OR REPLACE INTO current_wallpaper (monitor, path, set_at)
+VALUES
+    (?, ?, ?)
+RETURNING
+    monitor,
+    path,
+    set_at
+`
+
+type UpdateCurrentWallpaperParams struct {
+	Monitor string
+	Path    string
+	SetAt   time.Time
+}
+
+func (q *Queries) UpdateCurrentWallpaper(ctx context.Context, arg UpdateCurrentWallpaperParams) (CurrentWallpaper, error) {
+	row := q.db.QueryRowContext(ctx, updateCurrentWallpaper, arg.Monitor, arg.Path, arg.SetAt)
+	var i CurrentWallpaper
+	err := row.Scan(&i.Monitor, &i.Path, &i.SetAt)
+	return i, err
+}
+
+const upsertFileHash = `-- name: UpsertFileHash :exec
+INSERT
+    OR REPLACE INTO file_hashes (path, size, mtime, hash)
+VALUES
+    (?, ?, ?, ?)
+`
+
+type UpsertFileHashParams struct {
+	Path  string
+	Size  int64
+	Mtime time.Time
+	Hash  string
+}
+
+func (q *Queries) UpsertFileHash(ctx context.Context, arg UpsertFileHashParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFileHash,
+		arg.Path,
+		arg.Size,
+		arg.Mtime,
+		arg.Hash,
+	)
+	return err
+}
+
+const upsertLibraryScan = `-- name: UpsertLibraryScan :exec
+INSERT
+    OR REPLACE INTO library_scans (root, recursive, scanned_at)
+VALUES
+    (?, ?, ?)
+`
+
+type UpsertLibraryScanParams struct {
+	Root      string
+	Recursive bool
+	ScannedAt time.Time
+}
+
+func (q *Queries) UpsertLibraryScan(ctx context.Context, arg UpsertLibraryScanParams) error {
+	_, err := q.db.ExecContext(ctx, upsertLibraryScan, arg.Root, arg.Recursive, arg.ScannedAt)
+	return err
+}
+
+const upsertLibraryWallpaper = `-- name: UpsertLibraryWallpaper :exec
+INSERT
+    OR REPLACE INTO wallpapers (root, path, size, mtime, width, height, format)
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+`
+
+type UpsertLibraryWallpaperParams struct {
+	Root   string
+	Path   string
+	Size   int64
+	Mtime  time.Time
+	Width  int64
+	Height int64
+	Format string
+}
+
+func (q *Queries) UpsertLibraryWallpaper(ctx context.Context, arg UpsertLibraryWallpaperParams) error {
+	_, err := q.db.ExecContext(ctx, upsertLibraryWallpaper,
+		arg.Root,
+		arg.Path,
+		arg.Size,
+		arg.Mtime,
+		arg.Width,
+		arg.Height,
+		arg.Format,
+	)
+	return err
+}
+
+const upsertPalette = `-- name: UpsertPalette :exec
+INSERT
+    OR REPLACE INTO palettes (path, size, mtime, count, colors)
+VALUES
+    (?, ?, ?, ?, ?)
+`
+
+type UpsertPaletteParams struct {
+	Path   string
+	Size   int64
+	Mtime  time.Time
+	Count  int64
+	Colors string
+}
+
+func (q *Queries) UpsertPalette(ctx context.Context, arg UpsertPaletteParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPalette,
+		arg.Path,
+		arg.Size,
+		arg.Mtime,
+		arg.Count,
+		arg.Colors,
+	)
+	return err
+}
+
+const upsertRandomCycle = `-- name: UpsertRandomCycle :exec
+INSERT OR REPLACE INTO random_cycle (id, shuffled_wallpapers, current_index) VALUES (1, ?, ?)
+`
+
+type UpsertRandomCycleParams struct {
+	ShuffledWallpapers string
+	CurrentIndex       int64
+}
+
 func (q *Queries) UpsertRandomCycle(ctx context.Context, arg UpsertRandomCycleParams) error {
 	_, err := q.db.ExecContext(ctx, upsertRandomCycle, arg.ShuffledWallpapers, arg.CurrentIndex)
 	return err
 }
+
+const upsertWorkspaceWallpaper = `-- name: UpsertWorkspaceWallpaper :exec
+INSERT
+    OR REPLACE INTO workspace_wallpapers (workspace, path)
+VALUES
+    (?, ?)
+`
+
+type UpsertWorkspaceWallpaperParams struct {
+	Workspace string
+	Path      string
+}
+
+func (q *Queries) UpsertWorkspaceWallpaper(ctx context.Context, arg UpsertWorkspaceWallpaperParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWorkspaceWallpaper, arg.Workspace, arg.Path)
+	return err
+}
@@ -0,0 +1,857 @@
+// Package engine implements wallpaper selection and state on top of a Backend.
+// It scans the wallpaper directories, picks the next/previous/random wallpaper,
+// keeps the random cycle and the history in the database and hands the chosen
+// image to the backend, so every backend gets the same behaviour for free.
+package engine
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/db/sqlc"
+	"github.com/marcosalvi-01/wallman/library"
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+// Backend applies wallpapers to outputs.
+type Backend interface {
+	// Apply sets the image at path on outputs. No outputs means all of them.
+	Apply(path, fit string, outputs []string) error
+	// Outputs lists the connected outputs.
+	Outputs() ([]string, error)
+}
+
+// Previewer is implemented by backends that can describe what Apply would do.
+// On a dry run the engine calls Preview instead of skipping the backend.
+type Previewer interface {
+	Preview(path, fit string, outputs []string) error
+}
+
+// Options configure an Engine.
+type Options struct {
+	WallpaperDirs []string
+	TravelSubDirs bool
+	// Independent gives every output its own selection when no monitor is
+	// specified, instead of one wallpaper shared by all of them.
+	Independent bool
+	// Span slices the wallpaper shared by all outputs across them according
+	// to their layout, when the backend is a GeometryProvider.
+	Span bool
+	// DryRun records the changes in the database without applying them.
+	DryRun bool
+	// FavoritesOnly restricts Next and Random to favorite wallpapers.
+	FavoritesOnly bool
+	// Tags restricts Next and Random to wallpapers having all of these tags.
+	Tags []string
+	// AutoTags tags wallpapers with the names of their subdirectories when
+	// TravelSubDirs is set.
+	AutoTags bool
+	// Strategy is the strategy Random uses when none is given, StrategyCycle
+	// if empty.
+	Strategy string
+	// RecentDays is the window of StrategyAvoidRecent, a week if zero.
+	RecentDays int
+	// ScanInterval is how old the library index may get before it is
+	// rescanned, see library.Options.MaxAge.
+	ScanInterval time.Duration
+	// Match makes Next and Random prefer wallpapers fitting the geometry of
+	// the monitor, when the backend is a GeometryProvider.
+	Match Match
+	// Fit is the fit mode used when neither the command nor an override
+	// gives one. If empty each command keeps its historical default.
+	Fit string
+	// DirectoryFits overrides Fit for the wallpapers inside a directory.
+	DirectoryFits map[string]string
+	// MonitorFits overrides Fit and DirectoryFits on a monitor.
+	MonitorFits map[string]string
+	// Pipelines are the named pipelines wallpapers can be processed with
+	// before being shown. The history keeps the original wallpapers.
+	Pipelines map[string]pipeline.Pipeline
+	// Pipeline names the pipeline of every wallpaper, none if empty.
+	Pipeline string
+	// TagPipelines overrides Pipeline for the wallpapers having a tag.
+	TagPipelines map[string]string
+	// MonitorPipelines overrides Pipeline and TagPipelines on a monitor.
+	MonitorPipelines map[string]string
+	// Hooks run around every wallpaper change, except on a dry run.
+	Hooks Hooks
+	// OnChange is called with the new wallpaper after every change, once per
+	// monitor, except on a dry run. Its error is logged, the change goes
+	// ahead.
+	OnChange func(path, monitor string) error
+	// Pool further restricts Next and Random, e.g. to the wallpapers of the
+	// active schedule rule.
+	Pool Pool
+	// Variant is the variant of the wallpapers (VariantLight or VariantDark)
+	// Next and Random pick when a wallpaper comes in both.
+	Variant string
+}
+
+// Pool is a subset of the wallpapers Next and Random draw from.
+type Pool struct {
+	// Dirs replace the wallpaper directories when not empty. They are
+	// indexed like the wallpaper directories.
+	Dirs []string
+	// Tags are required in addition to Options.Tags.
+	Tags []string
+	// Playlist names a playlist whose items replace the wallpapers, Dirs
+	// included. Next, Previous and Forward then walk it in order from where
+	// it was left and Random keeps a cycle for it.
+	Playlist string
+}
+
+type Engine struct {
+	backend     Backend
+	wallpapers  []string
+	candidates  []string
+	sizes       map[string]Geometry
+	allowed     map[string]bool
+	favorites   map[string]bool
+	ratings     map[string]int
+	queries     *sqlc.Queries
+	independent bool
+	span        bool
+	dryRun      bool
+	strategy    string
+	recentDays  int
+	match       Match
+	fit         string
+	// directoryFits and monitorFits are the fit overrides, see resolveFit.
+	directoryFits map[string]string
+	monitorFits   map[string]string
+	// pipelines and the selections of pipelines, see pipelineFor.
+	pipelines        map[string]pipeline.Pipeline
+	pipeline         string
+	tagPipelines     map[string]string
+	monitorPipelines map[string]string
+	// tagOptions are the options Tags needs to find the tags of a wallpaper.
+	tagOptions Options
+	// geometry caches the geometry of the outputs, see outputGeometry.
+	geometry map[string]Geometry
+	hooks    Hooks
+	onChange func(path, monitor string) error
+	variant  string
+	// playlist is the playlist being played, nil if none.
+	playlist *playlist
+}
+
+func New(backend Backend, options Options, queries *sqlc.Queries) (*Engine, error) {
+	var (
+		walls  []string
+		sizes  map[string]Geometry
+		played *playlist
+		err    error
+	)
+	if options.Pool.Playlist != "" {
+		played, walls, sizes, err = loadPlaylist(options.Pool.Playlist)
+	} else {
+		walls, sizes, err = loadLibrary(options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := db.GetWallpaperFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	wantTags := slices.Concat(options.Tags, options.Pool.Tags)
+	var tags map[string][]string
+	if len(wantTags) > 0 {
+		tags, err = Tags(walls, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Banned wallpapers are never picked, and with FavoritesOnly, Tags or a
+	// Pool only the matching ones are. With a Variant, wallpapers coming in
+	// both variants are only picked in that one.
+	others := otherVariants(walls, options.Variant)
+	candidates := make([]string, 0, len(walls))
+	allowed := make(map[string]bool, len(walls))
+	favorites := make(map[string]bool)
+	ratings := make(map[string]int)
+	for _, wall := range walls {
+		flag := flags[wall]
+		if flag.Favorite {
+			favorites[wall] = true
+		}
+		if flag.Rating > 0 {
+			ratings[wall] = int(flag.Rating)
+		}
+		if flag.Banned || (options.FavoritesOnly && !flag.Favorite) || others[wall] {
+			continue
+		}
+		if !HasTags(tags[wall], wantTags) {
+			continue
+		}
+		candidates = append(candidates, wall)
+		allowed[wall] = true
+	}
+
+	return &Engine{
+		backend:     backend,
+		wallpapers:  walls,
+		candidates:  candidates,
+		sizes:       sizes,
+		allowed:     allowed,
+		favorites:   favorites,
+		ratings:     ratings,
+		queries:     queries,
+		independent: options.Independent,
+		span:        options.Span,
+		dryRun:      options.DryRun,
+		strategy:    options.Strategy,
+		recentDays:  options.RecentDays,
+		match:       options.Match,
+		fit:         options.Fit,
+		// Overrides are matched against cleaned paths.
+		directoryFits:    cleanDirs(options.DirectoryFits),
+		monitorFits:      options.MonitorFits,
+		pipelines:        options.Pipelines,
+		pipeline:         options.Pipeline,
+		tagPipelines:     options.TagPipelines,
+		monitorPipelines: options.MonitorPipelines,
+		tagOptions:       options,
+		hooks:            options.Hooks,
+		onChange:         options.OnChange,
+		variant:          options.Variant,
+		playlist:         played,
+	}, nil
+}
+
+// loadLibrary returns the indexed wallpapers of the directories of options,
+// in alphabetical order, and their geometry.
+func loadLibrary(options Options) ([]string, map[string]Geometry, error) {
+	indexed, err := library.Load(library.Options{
+		Dirs:      wallpaperDirs(options),
+		Recursive: options.TravelSubDirs,
+		MaxAge:    options.ScanInterval,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	walls := make([]string, len(indexed))
+	sizes := make(map[string]Geometry, len(indexed))
+	for i, wall := range indexed {
+		walls[i] = wall.Path
+		sizes[wall.Path] = Geometry{Width: int(wall.Width), Height: int(wall.Height)}
+	}
+	return walls, sizes, nil
+}
+
+// wallpaperDirs returns the directories the wallpapers of options come from:
+// the ones of the pool, if any, or else the wallpaper directories.
+func wallpaperDirs(options Options) []string {
+	if len(options.Pool.Dirs) > 0 {
+		return options.Pool.Dirs
+	}
+	return options.WallpaperDirs
+}
+
+// Next sets the wallpaper following the current one in alphabetical order,
+// or the item following the one shown last when a playlist is played. A
+// non-empty fit overrides the configured fit mode.
+func (e *Engine) Next(monitor, fit string) error {
+	if len(e.candidates) == 0 {
+		return fmt.Errorf("no wallpapers available")
+	}
+	if err := checkFit(fit); err != nil {
+		return err
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	// Monitors handled in the same call never get the same wallpaper.
+	taken := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		path, err := e.next(target, taken, fit, ActionNext)
+		if err != nil {
+			return err
+		}
+		taken[path] = true
+	}
+
+	return nil
+}
+
+func (e *Engine) next(monitor string, taken map[string]bool, fit, action string) (string, error) {
+	fitting, err := e.fitting(monitor)
+	if err != nil {
+		return "", err
+	}
+
+	index := -1
+	if e.playlist != nil {
+		index = e.playlist.index(e.wallpapers)
+	} else if current, err := db.GetCurrentWallpaperPath(monitor); err == nil {
+		index = slices.Index(e.wallpapers, current)
+	}
+
+	// Walk the full list so that the order is kept even when the current
+	// wallpaper is no longer a candidate.
+	path := ""
+	for step := 1; step <= len(e.wallpapers); step++ {
+		wall := e.wallpapers[(index+step+len(e.wallpapers))%len(e.wallpapers)]
+		if !e.allowed[wall] || (fitting != nil && !fitting[wall]) {
+			continue
+		}
+		if path == "" {
+			path = wall
+		}
+		if !taken[wall] {
+			path = wall
+			break
+		}
+	}
+
+	fit = e.resolveFit(path, monitor, fit, FitCover)
+	change := e.begin(action, path, monitor, fit)
+	err = db.SetWallpaper(path, monitor, fit)
+	if err != nil {
+		return "", err
+	}
+	err = e.moveCursor(path)
+	if err != nil {
+		return "", err
+	}
+
+	err = e.apply(path, monitor, fit)
+	if err != nil {
+		return "", fmt.Errorf("failed to set %s wallpaper: %w", action, err)
+	}
+	e.finish(change)
+
+	return path, nil
+}
+
+// Previous goes steps entries back in the history of monitor, or steps items
+// back in the playlist when one is played.
+func (e *Engine) Previous(monitor string, steps int) error {
+	if e.playlist != nil {
+		if steps < 1 {
+			return fmt.Errorf("steps must be at least 1")
+		}
+		return e.seek(monitor, -steps-1, ActionPrevious)
+	}
+	return e.travel(monitor, steps, db.GetPreviousWallpaper, ActionPrevious)
+}
+
+// Forward undoes Previous, going steps entries forward in the history of
+// monitor, or steps items forward in the playlist when one is played.
+func (e *Engine) Forward(monitor string, steps int) error {
+	if e.playlist != nil {
+		if steps < 1 {
+			return fmt.Errorf("steps must be at least 1")
+		}
+		return e.seek(monitor, steps-1, ActionForward)
+	}
+	return e.travel(monitor, steps, db.GetNextWallpaper, ActionForward)
+}
+
+// travel moves the current wallpaper through history without adding entries,
+// so Previous and Forward can walk back and forth over the same history. The
+// wallpapers get back the fit mode they were shown with.
+func (e *Engine) travel(monitor string, steps int, find func(string, int) (sqlc.WallpaperHistory, error), direction string) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		entry, err := find(target, steps)
+		if err != nil {
+			return err
+		}
+
+		// Entries from before fit modes were recorded have none.
+		fit := entry.Fit
+		if fit == "" {
+			fit = e.resolveFit(entry.Path, target, "", FitCover)
+		}
+		change := e.begin(direction, entry.Path, target, fit)
+
+		err = db.SetCurrentWallpaper(entry.Path, target, entry.SetAt)
+		if err != nil {
+			return err
+		}
+
+		err = e.apply(entry.Path, target, fit)
+		if err != nil {
+			return fmt.Errorf("failed to set %s wallpaper: %w", direction, err)
+		}
+		e.finish(change)
+	}
+
+	return nil
+}
+
+// Random sets a random wallpaper picked with strategy, or with the strategy
+// of the options when it is empty. A non-empty fit overrides the configured
+// fit mode.
+func (e *Engine) Random(monitor, strategy, fit string) error {
+	if len(e.candidates) == 0 {
+		return fmt.Errorf("no wallpapers available")
+	}
+	if err := checkFit(fit); err != nil {
+		return err
+	}
+
+	if strategy == "" {
+		strategy = e.strategy
+	}
+	if strategy == "" {
+		strategy = StrategyCycle
+	}
+
+	var picker Strategy
+	if strategy != StrategyCycle {
+		var err error
+		picker, err = e.picker(strategy)
+		if err != nil {
+			return err
+		}
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if picker == nil {
+			err = e.cycle(target, fit)
+		} else {
+			err = e.pick(target, picker, strategy, fit)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pick sets the wallpaper chosen by picker among the candidates.
+func (e *Engine) pick(monitor string, picker Strategy, strategy, fit string) error {
+	fitting, err := e.fitting(monitor)
+	if err != nil {
+		return err
+	}
+
+	shown, err := db.GetLastShown()
+	if err != nil {
+		return err
+	}
+
+	candidates := make([]Candidate, 0, len(e.candidates))
+	for _, path := range e.candidates {
+		if fitting != nil && !fitting[path] {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Path:      path,
+			Favorite:  e.favorites[path],
+			Rating:    e.ratings[path],
+			LastShown: shown[path],
+		})
+	}
+
+	index, err := picker.Pick(candidates)
+	if err != nil {
+		return err
+	}
+	path := candidates[index].Path
+
+	// Uniform picks have always been filled, unlike the cycle.
+	fallback := FitCover
+	if strategy == StrategyUniform {
+		fallback = FitFill
+	}
+	fit = e.resolveFit(path, monitor, fit, fallback)
+	change := e.begin(ActionRandom, path, monitor, fit)
+	err = db.SetWallpaper(path, monitor, fit)
+	if err != nil {
+		return err
+	}
+
+	err = e.apply(path, monitor, fit)
+	if err != nil {
+		return fmt.Errorf("failed to set random wallpaper: %w", err)
+	}
+	e.finish(change)
+
+	return nil
+}
+
+// cycle sets the next wallpaper of the shuffled cycle kept in the database,
+// for the played playlist if any.
+func (e *Engine) cycle(monitor, fit string) error {
+	fitting, err := e.fitting(monitor)
+	if err != nil {
+		return err
+	}
+
+	shuffled, index, err := e.loadCycle()
+	if err != nil {
+		return err
+	}
+
+	if fitting != nil && !fitting[shuffled[index]] {
+		// Bring the next fitting wallpaper forward, the skipped ones stay in
+		// the cycle for the other monitors.
+		next := slices.IndexFunc(shuffled[index:], func(path string) bool { return fitting[path] })
+		if next < 0 {
+			// Every fitting wallpaper was already shown in this cycle: show
+			// one of them again without advancing.
+			return e.repeat(monitor, shuffled[:index], fitting, fit)
+		}
+		shuffled[index], shuffled[index+next] = shuffled[index+next], shuffled[index]
+	}
+
+	path := shuffled[index]
+	fit = e.resolveFit(path, monitor, fit, FitCover)
+	change := e.begin(ActionRandom, path, monitor, fit)
+	err = db.SetWallpaper(path, monitor, fit)
+	if err != nil {
+		return err
+	}
+
+	err = e.apply(path, monitor, fit)
+	if err != nil {
+		return fmt.Errorf("failed to set random wallpaper: %w", err)
+	}
+	e.finish(change)
+
+	// Advance index
+	index++
+	if index >= len(shuffled) {
+		// Cycle complete, reshuffle for next
+		common.ShuffleSlice(shuffled)
+		index = 0
+	}
+	err = e.saveCycle(shuffled, index)
+	if err != nil {
+		return fmt.Errorf("failed to update random cycle: %w", err)
+	}
+
+	return nil
+}
+
+// repeat sets a random wallpaper among the fitting ones of shown.
+func (e *Engine) repeat(monitor string, shown []string, fitting map[string]bool, fit string) error {
+	var paths []string
+	for _, path := range shown {
+		if fitting[path] {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no wallpapers available")
+	}
+
+	path := paths[rand.IntN(len(paths))]
+	fit = e.resolveFit(path, monitor, fit, FitCover)
+	change := e.begin(ActionRandom, path, monitor, fit)
+	err := db.SetWallpaper(path, monitor, fit)
+	if err != nil {
+		return err
+	}
+
+	err = e.apply(path, monitor, fit)
+	if err != nil {
+		return fmt.Errorf("failed to set random wallpaper: %w", err)
+	}
+	e.finish(change)
+	return nil
+}
+
+// CycleStatus reports how many wallpapers of the random cycle were already
+// shown and how many it holds, as if it was synced with the candidates. It
+// only reads the cycle, the next Random stores it synced.
+func (e *Engine) CycleStatus() (shown, total int, err error) {
+	if len(e.candidates) == 0 {
+		return 0, 0, nil
+	}
+
+	shuffled, index, err := e.getCycle()
+	if err != nil {
+		// No cycle yet.
+		shuffled, index = nil, 0
+	}
+	synced, index := syncCycle(shuffled, index, e.candidates)
+	if index >= len(synced) {
+		// Random starts a new cycle.
+		index = 0
+	}
+	return index, len(synced), nil
+}
+
+// SyncCycle brings the stored random cycle up to date with the candidates
+// right away instead of on the next Random.
+func (e *Engine) SyncCycle() error {
+	if len(e.candidates) == 0 {
+		return nil
+	}
+	_, _, err := e.loadCycle()
+	return err
+}
+
+// Sync moves what is stored about the wallpapers renamed in change to their
+// new path and drops the removed ones from the history and the current
+// wallpapers, so that they never point to missing files. Renamed wallpapers also keep their
+// place in the random cycles.
+func Sync(change library.Change) error {
+	for oldPath, newPath := range change.Renamed {
+		err := db.RenameWallpaper(oldPath, newPath)
+		if err != nil {
+			return err
+		}
+	}
+	for _, path := range change.Removed {
+		err := db.ForgetWallpaper(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(change.Renamed) == 0 {
+		return nil
+	}
+	// Without a cycle yet there is nothing to rename.
+	shuffled, index, err := db.GetRandomCycle()
+	if err == nil && renameCycle(shuffled, change.Renamed) {
+		err = db.UpsertRandomCycle(shuffled, index)
+		if err != nil {
+			return err
+		}
+	}
+	return renamePlaylistCycles(change.Renamed)
+}
+
+// loadCycle returns the random cycle with the library changes applied, so
+// that its progress survives wallpapers being added or removed. The returned
+// index always points into the cycle.
+func (e *Engine) loadCycle() ([]string, int, error) {
+	shuffled, index, err := e.getCycle()
+	if err != nil {
+		// If no cycle, initialize it
+		shuffled, index = nil, 0
+	}
+
+	synced, index := syncCycle(shuffled, index, e.candidates)
+	if index >= len(synced) {
+		// Everything left in the cycle is gone, start a new one.
+		common.ShuffleSlice(synced)
+		index = 0
+	}
+
+	if !slices.Equal(synced, shuffled) {
+		err = e.saveCycle(synced, index)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to update random cycle: %w", err)
+		}
+	}
+
+	return synced, index, nil
+}
+
+// syncCycle drops the wallpapers of shuffled that are no longer candidates
+// and inserts the new candidates at random positions among the ones not
+// shown yet, i.e. from index on.
+func syncCycle(shuffled []string, index int, candidates []string) ([]string, int) {
+	isCandidate := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		isCandidate[candidate] = true
+	}
+
+	synced := make([]string, 0, len(candidates))
+	inCycle := make(map[string]bool, len(shuffled))
+	shown := 0
+	for i, path := range shuffled {
+		if !isCandidate[path] || inCycle[path] {
+			continue
+		}
+		synced = append(synced, path)
+		inCycle[path] = true
+		if i < index {
+			shown++
+		}
+	}
+
+	for _, candidate := range candidates {
+		if inCycle[candidate] {
+			continue
+		}
+		position := shown + rand.IntN(len(synced)-shown+1)
+		synced = slices.Insert(synced, position, candidate)
+		inCycle[candidate] = true
+	}
+
+	return synced, shown
+}
+
+// Set sets the wallpaper at path, which does not have to be in the wallpaper
+// directories. A non-empty fit overrides the configured fit mode.
+func (e *Engine) Set(path, monitor, fit string) error {
+	path = common.ExpandPath(path)
+	if err := checkFit(fit); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to access wallpaper file: %w", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("path is not a regular file")
+	}
+
+	if _, err := common.Sniff(path); err != nil {
+		return err
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		targetFit := e.resolveFit(path, target, fit, FitFill)
+		change := e.begin(ActionSet, path, target, targetFit)
+		err = db.SetWallpaper(path, target, targetFit)
+		if err != nil {
+			return fmt.Errorf("failed to set wallpaper in database: %w", err)
+		}
+		// The playlist goes on after an item set by hand.
+		err = e.moveCursor(path)
+		if err != nil {
+			return err
+		}
+
+		err = e.apply(path, target, targetFit)
+		if err != nil {
+			return fmt.Errorf("failed to set wallpaper: %w", err)
+		}
+		e.finish(change)
+	}
+
+	return nil
+}
+
+func (e *Engine) Monitors() ([]string, error) {
+	return e.backend.Outputs()
+}
+
+// targets resolves the monitors a command acts on. The empty string stands
+// for the wallpaper shared by all monitors.
+func (e *Engine) targets(monitor string) ([]string, error) {
+	if monitor != "" {
+		if e.dryRun {
+			return []string{monitor}, nil
+		}
+		monitors, err := e.backend.Outputs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list monitors: %w", err)
+		}
+		if !slices.Contains(monitors, monitor) {
+			return nil, fmt.Errorf("unknown monitor %s (available: %s)", monitor, strings.Join(monitors, ", "))
+		}
+		return []string{monitor}, nil
+	}
+
+	if e.independent {
+		monitors, err := e.backend.Outputs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list monitors: %w", err)
+		}
+		if len(monitors) == 0 {
+			return nil, fmt.Errorf("no monitors found")
+		}
+		return monitors, nil
+	}
+
+	return []string{""}, nil
+}
+
+// apply hands the wallpaper to the backend, or to its previewer on a dry run,
+// after running it through its pipeline. The shared wallpaper is handed over
+// once per fit mode and derived image when they differ between monitors.
+func (e *Engine) apply(path, monitor, fit string) error {
+	var show func(path, fit string, outputs []string) error
+	if e.dryRun {
+		previewer, ok := e.backend.(Previewer)
+		if !ok {
+			return nil
+		}
+		show = previewer.Preview
+	} else {
+		show = e.backend.Apply
+	}
+
+	if monitor == "" && e.span {
+		return e.applySpan(path, fit, show)
+	}
+	if monitor != "" {
+		shown, err := e.prepare(path, monitor)
+		if err != nil {
+			return err
+		}
+		return show(shown, fit, []string{monitor})
+	}
+	if len(e.monitorFits) == 0 && !e.processing() {
+		return show(path, fit, nil)
+	}
+
+	outputs, err := e.backend.Outputs()
+	if err != nil {
+		return fmt.Errorf("failed to list monitors: %w", err)
+	}
+	type shown struct{ path, fit string }
+	groups := make(map[shown][]string)
+	for _, output := range outputs {
+		outputPath, err := e.prepare(path, output)
+		if err != nil {
+			return err
+		}
+		key := shown{outputPath, e.outputFit(output, fit)}
+		groups[key] = append(groups[key], output)
+	}
+
+	if len(groups) <= 1 {
+		// Every monitor gets the same image, keep handing over all of them.
+		key := shown{path, fit}
+		for groupKey := range groups {
+			key = groupKey
+		}
+		return show(key.path, key.fit, nil)
+	}
+	keys := slices.SortedFunc(maps.Keys(groups), func(a, b shown) int {
+		return cmp.Or(cmp.Compare(a.fit, b.fit), cmp.Compare(a.path, b.path))
+	})
+	for _, key := range keys {
+		err = show(key.path, key.fit, groups[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
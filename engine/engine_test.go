@@ -0,0 +1,642 @@
+package engine_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/library"
+)
+
+type applied struct {
+	path    string
+	fit     string
+	outputs []string
+}
+
+type fakeBackend struct {
+	outputs []string
+	applied []applied
+}
+
+func (f *fakeBackend) Apply(path, fit string, outputs []string) error {
+	f.applied = append(f.applied, applied{path: path, fit: fit, outputs: outputs})
+	return nil
+}
+
+func (f *fakeBackend) Outputs() ([]string, error) {
+	return f.outputs, nil
+}
+
+type previewBackend struct {
+	fakeBackend
+	previewed []string
+}
+
+func (p *previewBackend) Preview(path, fit string, outputs []string) error {
+	p.previewed = append(p.previewed, path)
+	return nil
+}
+
+// imageData returns the content of a tiny image in the format matching the
+// extension of name, or text for other names.
+func imageData(t *testing.T, name string) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	var buf bytes.Buffer
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png":
+		err = png.Encode(&buf, img)
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case ".bmp":
+		header := make([]byte, 54)
+		copy(header, "BM")
+		binary.LittleEndian.PutUint32(header[14:], 40)
+		binary.LittleEndian.PutUint32(header[18:], 2)
+		binary.LittleEndian.PutUint32(header[22:], 1)
+		buf.Write(header)
+	case ".webp":
+		// A lossless header holding the width and height minus one.
+		buf.WriteString("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00\x2f")
+		buf.Write([]byte{1, 0, 0, 0})
+		buf.Write(make([]byte, 12))
+	default:
+		buf.WriteString("not an image")
+	}
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", name, err)
+	}
+	return buf.Bytes()
+}
+
+// setup creates a wallpaper directory with files and points the database to a
+// temporary home directory.
+func setup(t *testing.T, files ...string) string {
+	t.Helper()
+
+	home := testutil.Home(t)
+
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), imageData(t, file), 0o600); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+	return dir
+}
+
+func newEngine(t *testing.T, backend engine.Backend, dir string, independent bool) *engine.Engine {
+	t.Helper()
+
+	e, err := engine.New(backend, engine.Options{WallpaperDirs: []string{dir}, Independent: independent}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return e
+}
+
+func TestNew(t *testing.T) {
+	dir := setup(t, "test.png", "image.jpeg", "test.jpg", "image.bmp", "test.webp", "IMG_001.JPG", "notimage.txt")
+	// A text file with an image extension is not a wallpaper.
+	if err := os.WriteFile(filepath.Join(dir, "fake.png"), []byte("not an image"), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	e := newEngine(t, &fakeBackend{}, dir, false)
+	if got := len(e.Wallpapers()); got != 6 {
+		t.Errorf("found %d wallpapers, want 6", got)
+	}
+}
+
+func TestNextWraps(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	backend := &fakeBackend{}
+	e := newEngine(t, backend, dir, false)
+
+	for _, want := range []string{"a.png", "b.png", "c.png", "a.png"} {
+		if err := e.Next("", ""); err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+		current, err := db.GetCurrentWallpaperPath("")
+		if err != nil {
+			t.Fatalf("Current() failed: %v", err)
+		}
+		if current != filepath.Join(dir, want) {
+			t.Errorf("Current() = %s, want %s", current, want)
+		}
+	}
+
+	last := backend.applied[len(backend.applied)-1]
+	if last.outputs != nil {
+		t.Errorf("shared wallpaper applied to %v, want all outputs", last.outputs)
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	for range 3 {
+		if err := e.Next("", ""); err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+	}
+	if err := e.Previous("", 1); err != nil {
+		t.Fatalf("Previous() failed: %v", err)
+	}
+
+	current, _ := db.GetCurrentWallpaperPath("")
+	if current != filepath.Join(dir, "b.png") {
+		t.Errorf("Current() = %s, want b.png", current)
+	}
+}
+
+func TestForward(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png", "d.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	for range 4 {
+		if err := e.Next("", ""); err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+	}
+
+	steps := []struct {
+		name    string
+		move    func() error
+		want    string
+		wantErr bool
+	}{
+		{"back two", func() error { return e.Previous("", 2) }, "b.png", false},
+		{"forward one", func() error { return e.Forward("", 1) }, "c.png", false},
+		{"forward past the end", func() error { return e.Forward("", 2) }, "c.png", true},
+		{"forward to the newest", func() error { return e.Forward("", 1) }, "d.png", false},
+		{"nothing newer", func() error { return e.Forward("", 1) }, "d.png", true},
+		{"back past the start", func() error { return e.Previous("", 4) }, "d.png", true},
+	}
+	for _, step := range steps {
+		err := step.move()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if current != filepath.Join(dir, step.want) {
+			t.Errorf("%s: Current() = %s, want %s", step.name, current, step.want)
+		}
+	}
+}
+
+func TestRandomCycle(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png", "d.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	seen := make(map[string]bool)
+	for range 4 {
+		if err := e.Random("", "", ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if seen[current] {
+			t.Errorf("%s repeated before the cycle completed", current)
+		}
+		seen[current] = true
+	}
+}
+
+func TestIndependentMonitors(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	backend := &fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}
+	e := newEngine(t, backend, dir, true)
+
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+
+	first, _ := db.GetCurrentWallpaperPath("DP-1")
+	second, _ := db.GetCurrentWallpaperPath("HDMI-A-1")
+	if first == second {
+		t.Errorf("both monitors show %s", first)
+	}
+
+	if len(backend.applied) != 2 || !slices.Equal(backend.applied[0].outputs, []string{"DP-1"}) {
+		t.Errorf("applied = %v, want one call per monitor", backend.applied)
+	}
+}
+
+func TestSingleMonitor(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	e := newEngine(t, &fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}, dir, false)
+
+	if err := e.Set(filepath.Join(dir, "a.png"), "", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := e.Set(filepath.Join(dir, "b.png"), "DP-1", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	if got, _ := db.GetCurrentWallpaperPath("DP-1"); got != filepath.Join(dir, "b.png") {
+		t.Errorf("Current(DP-1) = %s, want b.png", got)
+	}
+	// Monitors without their own wallpaper show the shared one.
+	if got, _ := db.GetCurrentWallpaperPath("HDMI-A-1"); got != filepath.Join(dir, "a.png") {
+		t.Errorf("Current(HDMI-A-1) = %s, want a.png", got)
+	}
+
+	if err := e.Next("eDP-1", ""); err == nil {
+		t.Error("Next() succeeded on an unknown monitor")
+	}
+}
+
+func TestSetRejectsNonImages(t *testing.T) {
+	dir := setup(t, "notes.txt")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	if err := e.Set(filepath.Join(dir, "notes.txt"), "", ""); err == nil {
+		t.Error("Set() accepted a text file")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := setup(t, "a.png")
+	backend := &previewBackend{}
+	e, err := engine.New(backend, engine.Options{WallpaperDirs: []string{dir}, DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if len(backend.applied) != 0 {
+		t.Errorf("dry run applied %v", backend.applied)
+	}
+	if !slices.Equal(backend.previewed, []string{filepath.Join(dir, "a.png")}) {
+		t.Errorf("previewed = %v, want a.png", backend.previewed)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	if err := db.SetBanned(filepath.Join(dir, "b.png"), true); err != nil {
+		t.Fatalf("SetBanned() failed: %v", err)
+	}
+	if err := db.SetFavorite(filepath.Join(dir, "c.png"), true); err != nil {
+		t.Fatalf("SetFavorite() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options engine.Options
+		want    []string
+	}{
+		{"banned skipped", engine.Options{}, []string{"a.png", "c.png"}},
+		{"favorites only", engine.Options{FavoritesOnly: true}, []string{"c.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.WallpaperDirs = []string{dir}
+			e, err := engine.New(&fakeBackend{}, tt.options, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(dir, name))
+			}
+			if !slices.Equal(e.Candidates(), want) {
+				t.Errorf("Candidates() = %v, want %v", e.Candidates(), want)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	if err := os.MkdirAll(filepath.Join(dir, "nature", "forest"), 0o750); err != nil {
+		t.Fatalf("Failed to create subdir: %v", err)
+	}
+	forest := filepath.Join(dir, "nature", "forest", "c.png")
+	if err := os.WriteFile(forest, imageData(t, forest), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := db.AddTag(filepath.Join(dir, "a.png"), "dark"); err != nil {
+		t.Fatalf("AddTag() failed: %v", err)
+	}
+	if err := db.AddTag(forest, "dark"); err != nil {
+		t.Fatalf("AddTag() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		autoTags bool
+		want     []string
+	}{
+		{"stored tag", []string{"dark"}, false, []string{filepath.Join(dir, "a.png"), forest}},
+		{"subdirectory without auto tags", []string{"forest"}, false, nil},
+		{"subdirectory", []string{"nature"}, true, []string{forest}},
+		{"all tags required", []string{"dark", "forest"}, true, []string{forest}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := engine.New(&fakeBackend{}, engine.Options{
+				WallpaperDirs: []string{dir},
+				TravelSubDirs: true,
+				Tags:          tt.tags,
+				AutoTags:      tt.autoTags,
+			}, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			got := slices.Clone(e.Candidates())
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPool(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	if err := os.MkdirAll(filepath.Join(dir, "night"), 0o750); err != nil {
+		t.Fatalf("Failed to create subdir: %v", err)
+	}
+	night := filepath.Join(dir, "night", "c.png")
+	if err := os.WriteFile(night, imageData(t, night), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := db.AddTag(filepath.Join(dir, "a.png"), "calm"); err != nil {
+		t.Fatalf("AddTag() failed: %v", err)
+	}
+	if err := db.AddTag(night, "calm"); err != nil {
+		t.Fatalf("AddTag() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		pool engine.Pool
+		want []string
+	}{
+		{"no pool", nil, engine.Pool{}, []string{filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png")}},
+		{"directory outside the wallpaper directories", nil, engine.Pool{Dirs: []string{filepath.Join(dir, "night")}}, []string{night}},
+		{"tags", nil, engine.Pool{Tags: []string{"calm"}}, []string{filepath.Join(dir, "a.png")}},
+		{"tags add up", []string{"dark"}, engine.Pool{Tags: []string{"calm"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := engine.New(&fakeBackend{}, engine.Options{
+				WallpaperDirs: []string{dir},
+				Tags:          tt.tags,
+				Pool:          tt.pool,
+			}, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			got := slices.Clone(e.Candidates())
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolDirTags(t *testing.T) {
+	dir := setup(t, "a.png")
+	pool := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(pool, "city"), 0o750); err != nil {
+		t.Fatalf("Failed to create subdir: %v", err)
+	}
+	city := filepath.Join(pool, "city", "b.png")
+	if err := os.WriteFile(city, imageData(t, city), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// The subdirectories are taken below the pool directory, which is not
+	// one of the wallpaper directories.
+	e, err := engine.New(&fakeBackend{}, engine.Options{
+		WallpaperDirs: []string{dir},
+		TravelSubDirs: true,
+		AutoTags:      true,
+		Pool:          engine.Pool{Dirs: []string{pool}, Tags: []string{"city"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := e.Candidates(); !slices.Equal(got, []string{city}) {
+		t.Errorf("Candidates() = %v, want %v", got, []string{city})
+	}
+}
+
+func TestLeastRecentStrategy(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	for _, name := range []string{"b.png", "a.png"} {
+		if err := e.Set(filepath.Join(dir, name), "", ""); err != nil {
+			t.Fatalf("Set() failed: %v", err)
+		}
+	}
+
+	// c.png was never shown, then b.png is the oldest.
+	for _, want := range []string{"c.png", "b.png", "a.png"} {
+		if err := e.Random("", engine.StrategyLeastRecent, ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if current != filepath.Join(dir, want) {
+			t.Errorf("Current() = %s, want %s", current, want)
+		}
+	}
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	a, b, c := filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png"), filepath.Join(dir, "c.png")
+	// a.png is 15 times as likely as b.png.
+	if err := db.SetFavorite(a, true); err != nil {
+		t.Fatalf("SetFavorite() failed: %v", err)
+	}
+	if err := db.SetRating(a, 5); err != nil {
+		t.Fatalf("SetRating() failed: %v", err)
+	}
+	if err := db.SetRating(b, 1); err != nil {
+		t.Fatalf("SetRating() failed: %v", err)
+	}
+	if err := db.SetBanned(c, true); err != nil {
+		t.Fatalf("SetBanned() failed: %v", err)
+	}
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	picked := make(map[string]int)
+	for range 200 {
+		if err := e.Random("", engine.StrategyWeighted, ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		picked[current]++
+	}
+	if picked[c] > 0 {
+		t.Errorf("the banned c.png was picked %d times", picked[c])
+	}
+	if picked[a] < 3*picked[b] {
+		t.Errorf("a.png was picked %d times and b.png %d times, want a.png far more often", picked[a], picked[b])
+	}
+}
+
+func TestAvoidRecentStrategy(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	for _, name := range []string{"b.png", "a.png"} {
+		if err := e.Set(filepath.Join(dir, name), "", ""); err != nil {
+			t.Fatalf("Set() failed: %v", err)
+		}
+	}
+
+	// c.png is the only one not shown recently, then every wallpaper was and
+	// the one shown the longest ago comes back.
+	for _, want := range []string{"c.png", "b.png", "a.png"} {
+		if err := e.Random("", engine.StrategyAvoidRecent, ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if current != filepath.Join(dir, want) {
+			t.Errorf("Current() = %s, want %s", current, want)
+		}
+	}
+}
+
+func TestCycleStatusOnlyReads(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	shown, total, err := e.CycleStatus()
+	if err != nil || shown != 0 || total != 3 {
+		t.Errorf("CycleStatus() = %d, %d, %v, want 0, 3", shown, total, err)
+	}
+	if _, _, err := db.GetRandomCycle(); err == nil {
+		t.Error("CycleStatus() stored a random cycle")
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	dir := setup(t, "a.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	if err := e.Random("", "sometimes", ""); err == nil {
+		t.Error("Random() accepted an unknown strategy")
+	}
+}
+
+func TestRandomCycleSurvivesChanges(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png", "d.png")
+	e := newEngine(t, &fakeBackend{}, dir, false)
+
+	seen := make(map[string]bool)
+	for range 2 {
+		if err := e.Random("", "", ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		seen[current] = true
+	}
+
+	// Replace a wallpaper that was not shown yet with a new one.
+	for _, name := range []string{"a.png", "b.png", "c.png", "d.png"} {
+		if path := filepath.Join(dir, name); !seen[path] {
+			if err := os.Remove(path); err != nil {
+				t.Fatalf("Failed to remove %s: %v", name, err)
+			}
+			break
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "e.png"), imageData(t, "e.png"), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	e = newEngine(t, &fakeBackend{}, dir, false)
+
+	shown, total, err := e.CycleStatus()
+	if err != nil {
+		t.Fatalf("CycleStatus() failed: %v", err)
+	}
+	if shown != 2 || total != 4 {
+		t.Errorf("CycleStatus() = %d, %d, want 2, 4", shown, total)
+	}
+
+	for range 2 {
+		if err := e.Random("", "", ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if seen[current] {
+			t.Errorf("%s repeated before the cycle completed", current)
+		}
+		seen[current] = true
+	}
+	if !seen[filepath.Join(dir, "e.png")] {
+		t.Error("new wallpaper was not part of the cycle")
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	e := newEngine(t, &fakeBackend{outputs: []string{"DP-1"}}, dir, false)
+
+	a, b, c := filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png"), filepath.Join(dir, "c.png")
+	if err := e.Set(a, "", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := e.Set(b, "DP-1", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := db.SetFavorite(a, true); err != nil {
+		t.Fatalf("SetFavorite() failed: %v", err)
+	}
+	if err := db.SetBanned(b, true); err != nil {
+		t.Fatalf("SetBanned() failed: %v", err)
+	}
+
+	err := engine.Sync(library.Change{Renamed: map[string]string{a: c}, Removed: []string{b}})
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+
+	// DP-1 lost its own wallpaper and falls back to the renamed shared one.
+	if got, _ := db.GetCurrentWallpaperPath("DP-1"); got != c {
+		t.Errorf("Current(DP-1) = %s, want c.png", got)
+	}
+	history, _ := db.GetWallpaperHistory("", 100)
+	if len(history) != 1 || history[0].Path != c {
+		t.Errorf("history = %v, want only c.png", history)
+	}
+	flags, _ := db.GetWallpaperFlags()
+	if !flags[c].Favorite || flags[a].Favorite {
+		t.Errorf("flags = %v, want the favorite moved to c.png", flags)
+	}
+	// A removed wallpaper may come back, with its flags.
+	if !flags[b].Banned {
+		t.Errorf("flags = %v, want b.png still banned", flags)
+	}
+}
@@ -0,0 +1,12 @@
+package engine
+
+// Wallpapers returns the wallpapers found in the configured directories, or
+// the items of the playlist in playing order.
+func (e *Engine) Wallpapers() []string {
+	return e.wallpapers
+}
+
+// Candidates returns the wallpapers Next and Random choose from.
+func (e *Engine) Candidates() []string {
+	return e.candidates
+}
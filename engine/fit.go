@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Fit modes, named after the ones of hyprpaper. Other backends map them onto
+// the closest mode they have.
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+	FitTile    = "tile"
+	FitFill    = "fill"
+)
+
+// Fits lists the valid fit modes.
+var Fits = []string{FitContain, FitCover, FitTile, FitFill}
+
+// ValidFit reports whether fit is a known fit mode.
+func ValidFit(fit string) bool {
+	return slices.Contains(Fits, fit)
+}
+
+func checkFit(fit string) error {
+	if fit != "" && !ValidFit(fit) {
+		return fmt.Errorf("unknown fit mode %q (expected one of %s)", fit, strings.Join(Fits, ", "))
+	}
+	return nil
+}
+
+// resolveFit returns the fit mode of the wallpaper at path on monitor: fit
+// when given, then the override of the monitor, then the one of the
+// deepest directory containing path, then the configured fit and finally
+// fallback. The override of a monitor is not considered for the shared
+// wallpaper, apply takes care of it.
+func (e *Engine) resolveFit(path, monitor, fit, fallback string) string {
+	if fit != "" {
+		return fit
+	}
+	if monitor != "" {
+		if fit, ok := e.monitorFits[monitor]; ok {
+			return fit
+		}
+	}
+
+	best := ""
+	for dir, dirFit := range e.directoryFits {
+		if len(dir) > len(best) && inDir(path, dir) {
+			best, fit = dir, dirFit
+		}
+	}
+	if fit != "" {
+		return fit
+	}
+
+	if e.fit != "" {
+		return e.fit
+	}
+	return fallback
+}
+
+// inDir reports whether path is inside dir, at any depth.
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// outputFit returns the fit mode of the shared wallpaper on output, applying
+// the monitor overrides on top of fit.
+func (e *Engine) outputFit(output, fit string) string {
+	if override, ok := e.monitorFits[output]; ok {
+		return override
+	}
+	return fit
+}
+
+func cleanDirs(fits map[string]string) map[string]string {
+	cleaned := make(map[string]string, len(fits))
+	for dir, fit := range fits {
+		cleaned[filepath.Clean(dir)] = fit
+	}
+	return cleaned
+}
@@ -0,0 +1,145 @@
+package engine_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+)
+
+func TestFit(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	tiles := filepath.Join(dir, "tiles")
+	if err := os.MkdirAll(tiles, 0o750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tiles, "pattern.png"), imageData(t, "pattern.png"), 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options engine.Options
+		action  func(e *engine.Engine) error
+		want    []applied
+	}{
+		{
+			name:   "next default",
+			action: func(e *engine.Engine) error { return e.Next("", "") },
+			want:   []applied{{path: "a.png", fit: engine.FitCover}},
+		},
+		{
+			name:   "set default",
+			action: func(e *engine.Engine) error { return e.Set(filepath.Join(dir, "a.png"), "", "") },
+			want:   []applied{{path: "a.png", fit: engine.FitFill}},
+		},
+		{
+			name:    "configured fit",
+			options: engine.Options{Fit: engine.FitContain},
+			action:  func(e *engine.Engine) error { return e.Set(filepath.Join(dir, "a.png"), "", "") },
+			want:    []applied{{path: "a.png", fit: engine.FitContain}},
+		},
+		{
+			name:    "command fit wins",
+			options: engine.Options{Fit: engine.FitContain},
+			action:  func(e *engine.Engine) error { return e.Random("", engine.StrategyUniform, engine.FitTile) },
+			want:    []applied{{fit: engine.FitTile}},
+		},
+		{
+			name:    "directory override",
+			options: engine.Options{Fit: engine.FitContain, DirectoryFits: map[string]string{tiles: engine.FitTile}},
+			action:  func(e *engine.Engine) error { return e.Set(filepath.Join(tiles, "pattern.png"), "DP-1", "") },
+			want:    []applied{{path: filepath.Join("tiles", "pattern.png"), fit: engine.FitTile, outputs: []string{"DP-1"}}},
+		},
+		{
+			name: "monitor override",
+			options: engine.Options{
+				DirectoryFits: map[string]string{tiles: engine.FitTile},
+				MonitorFits:   map[string]string{"HDMI-A-1": engine.FitContain},
+			},
+			action: func(e *engine.Engine) error {
+				return e.Set(filepath.Join(tiles, "pattern.png"), "HDMI-A-1", "")
+			},
+			want: []applied{{path: filepath.Join("tiles", "pattern.png"), fit: engine.FitContain, outputs: []string{"HDMI-A-1"}}},
+		},
+		{
+			name:    "shared wallpaper with monitor override",
+			options: engine.Options{MonitorFits: map[string]string{"HDMI-A-1": engine.FitContain}},
+			action:  func(e *engine.Engine) error { return e.Next("", "") },
+			want: []applied{
+				{path: "a.png", fit: engine.FitContain, outputs: []string{"HDMI-A-1"}},
+				{path: "a.png", fit: engine.FitCover, outputs: []string{"DP-1"}},
+			},
+		},
+		{
+			name:   "unknown fit",
+			action: func(e *engine.Engine) error { return e.Next("", "stretch") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.Home(t)
+			backend := &fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}
+			options := tt.options
+			options.WallpaperDirs = []string{dir}
+			e, err := engine.New(backend, options, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			err = tt.action(e)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatal("action succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("action failed: %v", err)
+			}
+
+			for i := range tt.want {
+				// Random picks are not checked.
+				if tt.want[i].path != "" {
+					tt.want[i].path = filepath.Join(dir, tt.want[i].path)
+				} else if len(backend.applied) > i {
+					tt.want[i].path = backend.applied[i].path
+				}
+			}
+			if !reflect.DeepEqual(backend.applied, tt.want) {
+				t.Errorf("applied %+v, want %+v", backend.applied, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviousRestoresFit(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	backend := &fakeBackend{}
+	e := newEngine(t, backend, dir, false)
+
+	if err := e.Set(filepath.Join(dir, "a.png"), "", engine.FitTile); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if err := e.Previous("", 1); err != nil {
+		t.Fatalf("Previous() failed: %v", err)
+	}
+	if err := e.Forward("", 1); err != nil {
+		t.Fatalf("Forward() failed: %v", err)
+	}
+
+	var fits []string
+	for _, a := range backend.applied {
+		fits = append(fits, a.fit)
+	}
+	want := []string{engine.FitTile, engine.FitCover, engine.FitTile, engine.FitCover}
+	if !reflect.DeepEqual(fits, want) {
+		t.Errorf("applied fits %v, want %v", fits, want)
+	}
+}
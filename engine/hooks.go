@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/db"
+)
+
+// DefaultHookTimeout is how long a hook may run when Hooks.Timeout is zero.
+const DefaultHookTimeout = 10 * time.Second
+
+// Actions reported to the hooks in WALLMAN_ACTION.
+const (
+	ActionNext     = "next"
+	ActionPrevious = "previous"
+	ActionForward  = "forward"
+	ActionRandom   = "random"
+	ActionSet      = "set"
+)
+
+// Hooks are shell commands run around every wallpaper change, once per
+// monitor. They get the change in the environment: WALLMAN_PATH (the new
+// wallpaper), WALLMAN_PREVIOUS, WALLMAN_MONITOR (empty for the wallpaper
+// shared by all monitors), WALLMAN_FIT and WALLMAN_ACTION (see the Action
+// constants). A failing hook is logged, the change goes ahead.
+type Hooks struct {
+	// PreSet runs before the wallpaper is applied.
+	PreSet []string
+	// PostSet runs after the wallpaper is applied.
+	PostSet []string
+	// Timeout stops a hook running longer, DefaultHookTimeout if zero.
+	Timeout time.Duration
+}
+
+// change is a wallpaper change the hooks and OnChange are told about.
+type change struct {
+	path    string
+	monitor string
+	// env is the environment of the hooks, nil when there are none.
+	env []string
+}
+
+// begin runs the pre-set hooks of a change of the wallpaper of monitor to
+// path. Nothing runs on a dry run.
+func (e *Engine) begin(action, path, monitor, fit string) change {
+	if e.dryRun {
+		return change{}
+	}
+	c := change{path: path, monitor: monitor}
+	if len(e.hooks.PreSet) == 0 && len(e.hooks.PostSet) == 0 {
+		return c
+	}
+
+	// There is no previous wallpaper on the first change.
+	previous, _ := db.GetCurrentWallpaperPath(monitor)
+	c.env = []string{
+		"WALLMAN_PATH=" + path,
+		"WALLMAN_PREVIOUS=" + previous,
+		"WALLMAN_MONITOR=" + monitor,
+		"WALLMAN_FIT=" + fit,
+		"WALLMAN_ACTION=" + action,
+	}
+	e.runHooks(e.hooks.PreSet, c)
+	return c
+}
+
+// finish calls OnChange, then runs the post-set hooks of c, so that they
+// see what OnChange wrote.
+func (e *Engine) finish(c change) {
+	if c.path == "" {
+		return
+	}
+	if e.onChange != nil {
+		if err := e.onChange(c.path, c.monitor); err != nil {
+			log.Printf("wallpaper set, but %v", err)
+		}
+	}
+	if c.env != nil {
+		e.runHooks(e.hooks.PostSet, c)
+	}
+}
+
+func (e *Engine) runHooks(hooks []string, c change) {
+	for _, hook := range hooks {
+		if err := e.runHook(hook, c); err != nil {
+			log.Printf("hook failed: %v", err)
+		}
+	}
+}
+
+func (e *Engine) runHook(hook string, c change) error {
+	timeout := e.hooks.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Env = append(os.Environ(), c.env...)
+	// Do not wait for background processes keeping the output open.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%q timed out after %s", hook, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%q: %w: %s", hook, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package engine_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+)
+
+func TestHooks(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	out := filepath.Join(t.TempDir(), "hooks.log")
+	log := `echo "$0 $WALLMAN_ACTION $(basename "$WALLMAN_PATH") $(basename "$WALLMAN_PREVIOUS") $WALLMAN_MONITOR $WALLMAN_FIT" >> ` + out
+	e, err := engine.New(&fakeBackend{}, engine.Options{
+		WallpaperDirs: []string{dir},
+		Hooks: engine.Hooks{
+			PreSet:  []string{strings.Replace(log, "$0", "pre", 1)},
+			PostSet: []string{"exit 1", strings.Replace(log, "$0", "post", 1)},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if err := e.Set(filepath.Join(dir, "b.png"), "", "tile"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := e.Previous("", 1); err != nil {
+		t.Fatalf("Previous() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read the hook output: %v", err)
+	}
+	// The failing hook does not stop the ones after it.
+	want := []string{
+		"pre next a.png   cover",
+		"post next a.png   cover",
+		"pre set b.png a.png  tile",
+		"post set b.png a.png  tile",
+		"pre previous a.png b.png  cover",
+		"post previous a.png b.png  cover",
+	}
+	got := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hooks ran as\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHookFailures(t *testing.T) {
+	dir := setup(t, "a.png", "b.png")
+	marker := filepath.Join(t.TempDir(), "ran")
+	backend := &fakeBackend{}
+	e, err := engine.New(backend, engine.Options{
+		WallpaperDirs: []string{dir},
+		Hooks: engine.Hooks{
+			PreSet:  []string{"exit 3", "exec sleep 5"},
+			PostSet: []string{"touch " + marker},
+			Timeout: 100 * time.Millisecond,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	started := time.Now()
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("Next() took %s, the hook was not stopped", elapsed)
+	}
+
+	// The change goes ahead regardless.
+	current, err := db.GetCurrentWallpaperPath("")
+	if err != nil || filepath.Base(current) != "a.png" {
+		t.Errorf("current wallpaper is %q (%v), want a.png", current, err)
+	}
+	if len(backend.applied) != 1 {
+		t.Errorf("applied %d wallpapers, want 1", len(backend.applied))
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("post-set hook did not run: %v", err)
+	}
+}
+
+func TestHooksDryRun(t *testing.T) {
+	dir := setup(t, "a.png")
+	marker := filepath.Join(t.TempDir(), "ran")
+	e, err := engine.New(&fakeBackend{}, engine.Options{
+		WallpaperDirs: []string{dir},
+		DryRun:        true,
+		Hooks:         engine.Hooks{PreSet: []string{"touch " + marker}, PostSet: []string{"touch " + marker}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if err := e.Next("", ""); err != nil {
+		t.Fatalf("Next() failed: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("hooks ran on a dry run")
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	dir := setup(t, "city-dark.png", "city-light.png")
+	tmp := t.TempDir()
+	seen, out := filepath.Join(tmp, "seen"), filepath.Join(tmp, "hooks.log")
+
+	var changes []string
+	newEngine := func(variant string, dryRun bool) *engine.Engine {
+		e, err := engine.New(&fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}, engine.Options{
+			WallpaperDirs: []string{dir},
+			Independent:   true,
+			DryRun:        dryRun,
+			Variant:       variant,
+			// The post-set hooks see what OnChange did.
+			Hooks: engine.Hooks{PostSet: []string{"cat " + seen + " >> " + out}},
+			OnChange: func(path, monitor string) error {
+				change := monitor + " " + filepath.Base(path)
+				changes = append(changes, change)
+				return os.WriteFile(seen, []byte(change+"\n"), 0o600)
+			},
+		}, nil)
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		return e
+	}
+
+	if err := newEngine("", false).Set(filepath.Join(dir, "city-dark.png"), "", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	// Swapping the variant bypasses the commands but is a change too.
+	if err := newEngine(engine.VariantLight, false).ApplyVariant("DP-1"); err != nil {
+		t.Fatalf("ApplyVariant() failed: %v", err)
+	}
+	if err := newEngine("", true).Set(filepath.Join(dir, "city-dark.png"), "DP-1", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	want := []string{"DP-1 city-dark.png", "HDMI-A-1 city-dark.png", "DP-1 city-light.png"}
+	if !slices.Equal(changes, want) {
+		t.Errorf("OnChange got %v, want %v", changes, want)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read the hook output: %v", err)
+	}
+	if got := strings.Split(strings.TrimSpace(string(data)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("post-set hooks saw %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// Match modes.
+const (
+	// MatchOff ignores the geometry of the outputs.
+	MatchOff = "off"
+	// MatchPrefer picks wallpapers fitting the output when there are any.
+	MatchPrefer = "prefer"
+	// MatchStrict only ever picks wallpapers fitting the output.
+	MatchStrict = "strict"
+)
+
+// MatchModes lists the valid match modes.
+var MatchModes = []string{MatchOff, MatchPrefer, MatchStrict}
+
+const (
+	defaultAspectTolerance = 0.2
+	defaultMinResolution   = 1
+)
+
+// Geometry is the size of an output in pixels, as seen by a wallpaper, and
+// its place in the layout of the outputs.
+type Geometry struct {
+	Width  int
+	Height int
+	// X and Y are the position of the output in the layout, in logical
+	// pixels.
+	X int
+	Y int
+	// Scale is the scale of the output: it covers Width/Scale logical pixels.
+	// Zero means 1.
+	Scale float64
+}
+
+// logical returns the size of the output in the layout.
+func (g Geometry) logical() (width, height float64) {
+	scale := g.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	return float64(g.Width) / scale, float64(g.Height) / scale
+}
+
+// GeometryProvider is implemented by backends that know the size of their
+// outputs. The engine uses it to pick wallpapers fitting each output.
+type GeometryProvider interface {
+	Geometry() (map[string]Geometry, error)
+}
+
+// Match configures how wallpapers are matched to the outputs they are shown on.
+type Match struct {
+	// Mode is MatchOff (the default), MatchPrefer or MatchStrict.
+	Mode string `yaml:"mode,omitempty"`
+	// AspectTolerance is how far the aspect ratio of an image may be from the
+	// one of the output, relative to the latter. Zero means 0.2.
+	AspectTolerance float64 `yaml:"aspect_tolerance,omitempty"`
+	// MinResolution is the fraction of the output size an image must at
+	// least have in both directions. Zero means 1, the full size.
+	MinResolution float64 `yaml:"min_resolution,omitempty"`
+}
+
+// fitting returns the candidates fitting the outputs of monitor, or nil when
+// every candidate may be used.
+func (e *Engine) fitting(monitor string) (map[string]bool, error) {
+	if e.match.Mode == "" || e.match.Mode == MatchOff {
+		return nil, nil
+	}
+
+	geometry, err := e.outputGeometry()
+	if err != nil {
+		if e.match.Mode == MatchStrict {
+			return nil, err
+		}
+		log.Printf("ignoring monitor geometry: %v", err)
+		return nil, nil
+	}
+
+	// The shared wallpaper has to fit every output, or the whole layout
+	// when it spans the outputs.
+	var outputs []Geometry
+	if monitor == "" && e.span && len(geometry) > 0 {
+		outputs = append(outputs, layout(geometry))
+	}
+	for name, size := range geometry {
+		if (monitor == "" && !e.span) || name == monitor {
+			outputs = append(outputs, size)
+		}
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	fit := make(map[string]bool)
+	for _, path := range e.candidates {
+		if e.fits(e.sizes[path], outputs) {
+			fit[path] = true
+		}
+	}
+
+	if len(fit) == 0 {
+		if e.match.Mode == MatchStrict {
+			if monitor == "" {
+				return nil, fmt.Errorf("no wallpaper fits the monitors")
+			}
+			return nil, fmt.Errorf("no wallpaper fits monitor %s", monitor)
+		}
+		return nil, nil
+	}
+	return fit, nil
+}
+
+// outputGeometry returns the geometry of the outputs, asking the backend
+// only once.
+func (e *Engine) outputGeometry() (map[string]Geometry, error) {
+	if e.geometry != nil {
+		return e.geometry, nil
+	}
+
+	provider, ok := e.backend.(GeometryProvider)
+	if !ok {
+		return nil, fmt.Errorf("the backend does not report monitor sizes")
+	}
+	geometry, err := provider.Geometry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitor sizes: %w", err)
+	}
+	e.geometry = geometry
+	return geometry, nil
+}
+
+// fits reports whether an image of size fits every output.
+func (e *Engine) fits(size Geometry, outputs []Geometry) bool {
+	if size.Width <= 0 || size.Height <= 0 {
+		return false
+	}
+
+	tolerance := e.match.AspectTolerance
+	if tolerance <= 0 {
+		tolerance = defaultAspectTolerance
+	}
+	minResolution := e.match.MinResolution
+	if minResolution <= 0 {
+		minResolution = defaultMinResolution
+	}
+
+	aspect := float64(size.Width) / float64(size.Height)
+	for _, output := range outputs {
+		if output.Width <= 0 || output.Height <= 0 {
+			continue
+		}
+		outputAspect := float64(output.Width) / float64(output.Height)
+		if math.Abs(aspect-outputAspect)/outputAspect > tolerance {
+			return false
+		}
+		if float64(size.Width) < float64(output.Width)*minResolution ||
+			float64(size.Height) < float64(output.Height)*minResolution {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package engine_test
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+)
+
+type geometryBackend struct {
+	fakeBackend
+	geometry map[string]engine.Geometry
+}
+
+func (g *geometryBackend) Geometry() (map[string]engine.Geometry, error) {
+	return g.geometry, nil
+}
+
+// writeImage writes a PNG of the given size to dir.
+func TestMatch(t *testing.T) {
+	dir := setup(t)
+	testutil.WriteImage(t, filepath.Join(dir, "landscape.png"), 160, 90, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "portrait.png"), 90, 160, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "small-portrait.png"), 45, 80, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "square.png"), 100, 100, color.Black)
+
+	portrait := map[string]engine.Geometry{"DP-1": {Width: 90, Height: 160}}
+	tests := []struct {
+		name     string
+		match    engine.Match
+		geometry map[string]engine.Geometry
+		monitor  string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "off",
+			match:    engine.Match{Mode: engine.MatchOff},
+			geometry: portrait,
+			want:     []string{"landscape.png", "portrait.png", "small-portrait.png", "square.png"},
+		},
+		{
+			name:     "portrait monitor",
+			match:    engine.Match{Mode: engine.MatchStrict},
+			geometry: portrait,
+			monitor:  "DP-1",
+			want:     []string{"portrait.png"},
+		},
+		{
+			name:     "lower resolution",
+			match:    engine.Match{Mode: engine.MatchStrict, MinResolution: 0.5},
+			geometry: portrait,
+			want:     []string{"portrait.png", "small-portrait.png"},
+		},
+		{
+			name:  "shared wallpaper fits every monitor",
+			match: engine.Match{Mode: engine.MatchStrict, AspectTolerance: 0.8, MinResolution: 0.5},
+			geometry: map[string]engine.Geometry{
+				"DP-1":     {Width: 90, Height: 160},
+				"HDMI-A-1": {Width: 80, Height: 80},
+			},
+			want: []string{"portrait.png", "small-portrait.png", "square.png"},
+		},
+		{
+			name:     "prefer falls back to every wallpaper",
+			match:    engine.Match{Mode: engine.MatchPrefer},
+			geometry: map[string]engine.Geometry{"DP-1": {Width: 1080, Height: 1920}},
+			want:     []string{"landscape.png", "portrait.png", "small-portrait.png", "square.png"},
+		},
+		{
+			name:     "strict fails without fitting wallpapers",
+			match:    engine.Match{Mode: engine.MatchStrict},
+			geometry: map[string]engine.Geometry{"DP-1": {Width: 1080, Height: 1920}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &geometryBackend{fakeBackend: fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}, geometry: tt.geometry}
+			e, err := engine.New(backend, engine.Options{WallpaperDirs: []string{dir}, Match: tt.match}, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			// Next walks every fitting wallpaper once before wrapping.
+			seen := make(map[string]bool)
+			for range 4 {
+				err := e.Next(tt.monitor, "")
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("Next() succeeded, want an error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Next() failed: %v", err)
+				}
+				current, err := db.GetCurrentWallpaperPath(tt.monitor)
+				if err != nil {
+					t.Fatalf("Current() failed: %v", err)
+				}
+				seen[filepath.Base(current)] = true
+			}
+			if len(seen) != len(tt.want) {
+				t.Errorf("Next() showed %v, want %v", seen, tt.want)
+			}
+			for _, name := range tt.want {
+				if !seen[name] {
+					t.Errorf("Next() never showed %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchRandom(t *testing.T) {
+	dir := setup(t)
+	testutil.WriteImage(t, filepath.Join(dir, "landscape-1.png"), 160, 90, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "landscape-2.png"), 160, 90, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "portrait.png"), 90, 160, color.Black)
+
+	backend := &geometryBackend{
+		fakeBackend: fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}},
+		geometry: map[string]engine.Geometry{
+			"DP-1":     {Width: 160, Height: 90},
+			"HDMI-A-1": {Width: 90, Height: 160},
+		},
+	}
+	e, err := engine.New(backend, engine.Options{
+		WallpaperDirs: []string{dir},
+		Independent:   true,
+		Match:         engine.Match{Mode: engine.MatchStrict},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	for _, strategy := range []string{engine.StrategyCycle, engine.StrategyUniform, engine.StrategyCycle, engine.StrategyCycle} {
+		if err := e.Random("", strategy, ""); err != nil {
+			t.Fatalf("Random(%s) failed: %v", strategy, err)
+		}
+		for monitor, want := range map[string]string{"DP-1": "landscape", "HDMI-A-1": "portrait"} {
+			current, err := db.GetCurrentWallpaperPath(monitor)
+			if err != nil {
+				t.Fatalf("Current(%s) failed: %v", monitor, err)
+			}
+			if got := filepath.Base(current); got[:len(want)] != want {
+				t.Errorf("Random(%s) set %s on %s, want a %s wallpaper", strategy, got, monitor, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+)
+
+// playlist is the playlist an Engine plays, see Pool.Playlist.
+type playlist struct {
+	name string
+	// position is the position of the item shown last, 0 before the first.
+	position int64
+	// positions maps the items to their position.
+	positions map[string]int64
+}
+
+// loadPlaylist returns the playlist called name, its items in playing order
+// and their geometry. Items that are gone or no longer images are skipped.
+func loadPlaylist(name string) (*playlist, []string, map[string]Geometry, error) {
+	stored, found, err := db.GetPlaylist(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !found {
+		return nil, nil, nil, fmt.Errorf("unknown playlist: %s", name)
+	}
+	items, err := db.GetPlaylistItems(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	p := &playlist{name: name, position: stored.Position, positions: make(map[string]int64, len(items))}
+	walls := make([]string, 0, len(items))
+	sizes := make(map[string]Geometry, len(items))
+	for _, item := range items {
+		info, err := common.Sniff(item.Path)
+		if err != nil {
+			// Items are kept when their file goes away, it may come back.
+			if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, common.ErrNotImage) {
+				return nil, nil, nil, fmt.Errorf("playlist %s: %w", name, err)
+			}
+			continue
+		}
+		walls = append(walls, item.Path)
+		sizes[item.Path] = Geometry{Width: info.Width, Height: info.Height}
+		p.positions[item.Path] = item.Position
+	}
+	return p, walls, sizes, nil
+}
+
+// index returns the index in walls, the items of the playlist, of the item
+// shown last, -1 before the first.
+func (p *playlist) index(walls []string) int {
+	index := -1
+	for i, wall := range walls {
+		if p.positions[wall] > p.position {
+			break
+		}
+		index = i
+	}
+	return index
+}
+
+// moveCursor records path as the item of the playlist shown last, so that
+// the playlist resumes after it. Paths that are not in the playlist are
+// ignored.
+func (e *Engine) moveCursor(path string) error {
+	if e.playlist == nil {
+		return nil
+	}
+	position, ok := e.playlist.positions[path]
+	if !ok {
+		return nil
+	}
+	e.playlist.position = position
+	return db.SetPlaylistPosition(e.playlist.name, position)
+}
+
+// seek moves the cursor of the playlist by offset times the number of
+// targets, counting only the allowed items, and sets the items following it
+// like Next does. It is how Previous and Forward walk a playlist.
+func (e *Engine) seek(monitor string, offset int, action string) error {
+	if len(e.candidates) == 0 {
+		return fmt.Errorf("no wallpapers available")
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	index := e.playlist.index(e.wallpapers)
+	if index < 0 {
+		// Before the first item is after the last one.
+		index = len(e.wallpapers) - 1
+	}
+	direction, moves := 1, offset*len(targets)
+	if moves < 0 {
+		direction, moves = -1, -moves
+	}
+	for moves > 0 {
+		index = (index + direction + len(e.wallpapers)) % len(e.wallpapers)
+		if e.allowed[e.wallpapers[index]] {
+			moves--
+		}
+	}
+	e.playlist.position = e.playlist.positions[e.wallpapers[index]]
+
+	taken := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		path, err := e.next(target, taken, "", action)
+		if err != nil {
+			return err
+		}
+		taken[path] = true
+	}
+	return nil
+}
+
+// getCycle returns the random cycle of the playlist, or the one of the
+// wallpapers when no playlist is played.
+func (e *Engine) getCycle() ([]string, int, error) {
+	if e.playlist != nil {
+		return db.GetPlaylistCycle(e.playlist.name)
+	}
+	return db.GetRandomCycle()
+}
+
+// saveCycle stores the random cycle returned by getCycle.
+func (e *Engine) saveCycle(shuffled []string, index int) error {
+	if e.playlist != nil {
+		return db.UpsertPlaylistCycle(e.playlist.name, shuffled, index)
+	}
+	return db.UpsertRandomCycle(shuffled, index)
+}
+
+// renamePlaylistCycles moves the renamed wallpapers of the random cycles of
+// every playlist to their new path.
+func renamePlaylistCycles(renamed map[string]string) error {
+	playlists, err := db.GetPlaylists()
+	if err != nil {
+		return err
+	}
+	for _, p := range playlists {
+		shuffled, index, err := db.GetPlaylistCycle(p.Name)
+		if err != nil {
+			return err
+		}
+		if renameCycle(shuffled, renamed) {
+			err = db.UpsertPlaylistCycle(p.Name, shuffled, index)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renameCycle replaces the renamed wallpapers of shuffled by their new path,
+// reporting whether there were any.
+func renameCycle(shuffled []string, renamed map[string]string) bool {
+	changed := false
+	for i, path := range shuffled {
+		if newPath, ok := renamed[path]; ok {
+			shuffled[i] = newPath
+			changed = true
+		}
+	}
+	return changed
+}
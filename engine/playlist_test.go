@@ -0,0 +1,158 @@
+package engine_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/library"
+)
+
+// newPlaylist creates a playlist of the files of dir, in order.
+func newPlaylist(t *testing.T, dir, name string, files ...string) {
+	t.Helper()
+
+	if err := db.CreatePlaylist(name); err != nil {
+		t.Fatalf("CreatePlaylist() failed: %v", err)
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.Join(dir, file)
+	}
+	if err := db.AddToPlaylist(name, paths); err != nil {
+		t.Fatalf("AddToPlaylist() failed: %v", err)
+	}
+}
+
+func playlistEngine(t *testing.T, backend engine.Backend, dir, name string) *engine.Engine {
+	t.Helper()
+
+	e, err := engine.New(backend, engine.Options{
+		WallpaperDirs: []string{dir},
+		Pool:          engine.Pool{Playlist: name},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return e
+}
+
+func TestPlaylist(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png", "d.png")
+	newPlaylist(t, dir, "mix", "c.png", "a.png", "d.png")
+	e := playlistEngine(t, &fakeBackend{}, dir, "mix")
+
+	if got, want := e.Wallpapers(), []string{filepath.Join(dir, "c.png"), filepath.Join(dir, "a.png"), filepath.Join(dir, "d.png")}; !slices.Equal(got, want) {
+		t.Errorf("Wallpapers() = %v, want %v", got, want)
+	}
+
+	steps := []struct {
+		name string
+		move func() error
+		want string
+	}{
+		{"next starts at the first item", func() error { return e.Next("", "") }, "c.png"},
+		{"next follows the playlist", func() error { return e.Next("", "") }, "a.png"},
+		{"next", func() error { return e.Next("", "") }, "d.png"},
+		{"next wraps", func() error { return e.Next("", "") }, "c.png"},
+		{"previous wraps", func() error { return e.Previous("", 1) }, "d.png"},
+		{"previous two", func() error { return e.Previous("", 2) }, "c.png"},
+		{"forward", func() error { return e.Forward("", 1) }, "a.png"},
+		{"set moves the cursor", func() error { return e.Set(filepath.Join(dir, "d.png"), "", "") }, "d.png"},
+		{"next after set", func() error { return e.Next("", "") }, "c.png"},
+	}
+	for _, step := range steps {
+		if err := step.move(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if current != filepath.Join(dir, step.want) {
+			t.Errorf("%s: Current() = %s, want %s", step.name, current, step.want)
+		}
+	}
+}
+
+func TestPlaylistResumes(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	newPlaylist(t, dir, "one", "a.png", "b.png", "c.png")
+	newPlaylist(t, dir, "two", "c.png", "b.png")
+
+	for _, step := range []struct{ playlist, want string }{
+		{"one", "a.png"},
+		{"one", "b.png"},
+		{"two", "c.png"},
+		{"one", "c.png"},
+		{"two", "b.png"},
+	} {
+		e := playlistEngine(t, &fakeBackend{}, dir, step.playlist)
+		if err := e.Next("", ""); err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		if current != filepath.Join(dir, step.want) {
+			t.Errorf("Next() on %s set %s, want %s", step.playlist, current, step.want)
+		}
+	}
+}
+
+func TestPlaylistRandomCycle(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png", "d.png")
+	newPlaylist(t, dir, "mix", "a.png", "c.png")
+	e := playlistEngine(t, &fakeBackend{}, dir, "mix")
+
+	seen := make(map[string]bool)
+	for range 2 {
+		if err := e.Random("", "", ""); err != nil {
+			t.Fatalf("Random() failed: %v", err)
+		}
+		current, _ := db.GetCurrentWallpaperPath("")
+		seen[current] = true
+	}
+	if !seen[filepath.Join(dir, "a.png")] || !seen[filepath.Join(dir, "c.png")] {
+		t.Errorf("Random() showed %v, want every item of the playlist once", seen)
+	}
+
+	shuffled, _, err := db.GetPlaylistCycle("mix")
+	if err != nil {
+		t.Fatalf("GetPlaylistCycle() failed: %v", err)
+	}
+	if len(shuffled) != 2 {
+		t.Errorf("playlist cycle = %v, want its 2 items", shuffled)
+	}
+	if _, _, err := db.GetRandomCycle(); err == nil {
+		t.Error("the cycle of every wallpaper was created, want it left alone")
+	}
+}
+
+func TestPlaylistUnknown(t *testing.T) {
+	dir := setup(t, "a.png")
+	_, err := engine.New(&fakeBackend{}, engine.Options{
+		WallpaperDirs: []string{dir},
+		Pool:          engine.Pool{Playlist: "missing"},
+	}, nil)
+	if err == nil {
+		t.Error("New() succeeded with an unknown playlist")
+	}
+}
+
+func TestPlaylistSync(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.png")
+	newPlaylist(t, dir, "mix", "a.png", "b.png")
+
+	a, b, c := filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png"), filepath.Join(dir, "c.png")
+	err := engine.Sync(library.Change{Renamed: map[string]string{a: c}, Removed: []string{b}})
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+
+	items, err := db.GetPlaylistItems("mix")
+	if err != nil {
+		t.Fatalf("GetPlaylistItems() failed: %v", err)
+	}
+	// Removed items stay in case they come back.
+	if len(items) != 2 || items[0].Path != c || items[1].Path != b {
+		t.Errorf("items = %v, want c.png and b.png", items)
+	}
+}
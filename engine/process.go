@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+// processing reports whether any wallpaper may go through a pipeline.
+func (e *Engine) processing() bool {
+	return e.pipeline != "" || len(e.monitorPipelines) > 0 || len(e.tagPipelines) > 0
+}
+
+// pipelineFor returns the name of the pipeline of the wallpaper at path on
+// monitor: the one of the monitor, then the one of the first of its tags
+// having one, then the default one.
+func (e *Engine) pipelineFor(path, monitor string) (string, error) {
+	if name, ok := e.monitorPipelines[monitor]; ok && monitor != "" {
+		return name, nil
+	}
+
+	if len(e.tagPipelines) > 0 {
+		tags, err := Tags([]string{path}, e.tagOptions)
+		if err != nil {
+			return "", err
+		}
+		// Tags are sorted, so the choice does not depend on map order.
+		for _, tag := range tags[path] {
+			if name, ok := e.tagPipelines[tag]; ok {
+				return name, nil
+			}
+		}
+	}
+
+	return e.pipeline, nil
+}
+
+// prepare returns the image to show for the wallpaper at path on monitor,
+// which is the wallpaper itself unless a pipeline applies. Dry runs never
+// process images.
+func (e *Engine) prepare(path, monitor string) (string, error) {
+	if e.dryRun || !e.processing() {
+		return path, nil
+	}
+
+	name, err := e.pipelineFor(path, monitor)
+	if err != nil || name == "" {
+		return path, err
+	}
+	p, ok := e.pipelines[name]
+	if !ok {
+		return "", fmt.Errorf("unknown pipeline %q", name)
+	}
+	if p.IsZero() {
+		return path, nil
+	}
+
+	var width, height int
+	if p.Resize && monitor != "" {
+		geometry, err := e.outputGeometry()
+		if err != nil {
+			log.Printf("not resizing %s: %v", path, err)
+		} else {
+			width, height = geometry[monitor].Width, geometry[monitor].Height
+		}
+	}
+
+	processed, err := pipeline.Process(path, p, width, height)
+	if errors.Is(err, pipeline.ErrUnsupported) {
+		log.Printf("showing %s unprocessed: %v", path, err)
+		return path, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to process wallpaper: %w", err)
+	}
+	return processed, nil
+}
@@ -0,0 +1,82 @@
+package engine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+func TestPipelines(t *testing.T) {
+	dir := setup(t, "a.png", "b.png", "c.webp")
+	if err := db.AddTag(filepath.Join(dir, "b.png"), "night"); err != nil {
+		t.Fatalf("AddTag() failed: %v", err)
+	}
+	cache, err := pipeline.CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() failed: %v", err)
+	}
+
+	backend := &geometryBackend{
+		fakeBackend: fakeBackend{outputs: []string{"DP-1", "HDMI-A-1", "eDP-1"}},
+		geometry: map[string]engine.Geometry{
+			"DP-1":     {Width: 4, Height: 2},
+			"HDMI-A-1": {Width: 2, Height: 4},
+		},
+	}
+	e, err := engine.New(backend, engine.Options{
+		WallpaperDirs: []string{dir},
+		Pipelines: map[string]pipeline.Pipeline{
+			"blur":  {Resize: true, Blur: 1},
+			"dark":  {Dim: 0.5},
+			"plain": {},
+		},
+		TagPipelines:     map[string]string{"night": "dark"},
+		MonitorPipelines: map[string]string{"DP-1": "blur", "HDMI-A-1": "plain"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		monitor   string
+		processed bool
+	}{
+		{"monitor pipeline", "a.png", "DP-1", true},
+		{"monitor pipeline wins over tags", "b.png", "HDMI-A-1", false},
+		{"tag pipeline", "b.png", "eDP-1", true},
+		{"no pipeline", "a.png", "eDP-1", false},
+		{"unsupported format", "c.webp", "DP-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.applied = nil
+			path := filepath.Join(dir, tt.path)
+			if err := e.Set(path, tt.monitor, ""); err != nil {
+				t.Fatalf("Set() failed: %v", err)
+			}
+
+			shown := backend.applied[0].path
+			if processed := filepath.Dir(shown) == cache; processed != tt.processed {
+				t.Errorf("backend got %s, want processed %v", shown, tt.processed)
+			}
+			if current, err := db.GetCurrentWallpaperPath(tt.monitor); err != nil || current != path {
+				t.Errorf("Current() = %s, %v, want the original %s", current, err, path)
+			}
+		})
+	}
+
+	// The shared wallpaper goes through the pipeline of every monitor, the
+	// monitors showing the same image get it at once.
+	backend.applied = nil
+	if err := e.Set(filepath.Join(dir, "a.png"), "", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if len(backend.applied) != 2 {
+		t.Fatalf("backend got %+v, want a processed and an original image", backend.applied)
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+// applySpan slices the wallpaper at path across the outputs as they are laid
+// out and hands every slice to its output. Dry runs show the whole image.
+func (e *Engine) applySpan(path, fit string, show func(path, fit string, outputs []string) error) error {
+	if e.dryRun {
+		return show(path, fit, nil)
+	}
+
+	geometry, err := e.outputGeometry()
+	if err != nil {
+		return fmt.Errorf("span mode needs the monitor layout: %w", err)
+	}
+
+	// Monitor pipelines would break the image at the seams, only the ones of
+	// the wallpaper apply.
+	var p pipeline.Pipeline
+	name, err := e.pipelineFor(path, "")
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		var ok bool
+		p, ok = e.pipelines[name]
+		if !ok {
+			return fmt.Errorf("unknown pipeline %q", name)
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(geometry))
+	outputs := make([]pipeline.Output, len(names))
+	for i, name := range names {
+		g := geometry[name]
+		width, height := g.logical()
+		outputs[i] = pipeline.Output{
+			Name:        name,
+			X:           float64(g.X),
+			Y:           float64(g.Y),
+			Width:       width,
+			Height:      height,
+			PixelWidth:  g.Width,
+			PixelHeight: g.Height,
+		}
+	}
+
+	sliced, err := pipeline.Span(path, p, outputs)
+	if errors.Is(err, pipeline.ErrUnsupported) {
+		log.Printf("showing %s on every monitor: %v", path, err)
+		return show(path, fit, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to span wallpaper: %w", err)
+	}
+
+	for _, name := range names {
+		err = show(sliced[name], fit, []string{name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layout returns the size of the bounding box of the outputs, in logical
+// pixels, which is what a spanned wallpaper has to fit.
+func layout(geometry map[string]Geometry) Geometry {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, g := range geometry {
+		width, height := g.logical()
+		minX, minY = math.Min(minX, float64(g.X)), math.Min(minY, float64(g.Y))
+		maxX, maxY = math.Max(maxX, float64(g.X)+width), math.Max(maxY, float64(g.Y)+height)
+	}
+	return Geometry{Width: int(math.Round(maxX - minX)), Height: int(math.Round(maxY - minY))}
+}
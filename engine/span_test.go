@@ -0,0 +1,61 @@
+package engine_test
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+func TestSpan(t *testing.T) {
+	dir := setup(t)
+	testutil.WriteImage(t, filepath.Join(dir, "wide.png"), 64, 16, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "square.png"), 16, 16, color.Black)
+	cache, err := pipeline.CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() failed: %v", err)
+	}
+
+	backend := &geometryBackend{
+		fakeBackend: fakeBackend{outputs: []string{"DP-1", "DP-2"}},
+		geometry: map[string]engine.Geometry{
+			"DP-1": {Width: 32, Height: 16, Scale: 1},
+			"DP-2": {Width: 64, Height: 32, X: 32, Scale: 2},
+		},
+	}
+	e, err := engine.New(backend, engine.Options{
+		WallpaperDirs: []string{dir},
+		Span:          true,
+		Match:         engine.Match{Mode: engine.MatchStrict, MinResolution: 0.5},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	// Only the wide wallpaper fits the layout of both monitors.
+	if err := e.Random("", engine.StrategyUniform, ""); err != nil {
+		t.Fatalf("Random() failed: %v", err)
+	}
+
+	if len(backend.applied) != 2 {
+		t.Fatalf("backend got %+v, want one slice per monitor", backend.applied)
+	}
+	for i, monitor := range []string{"DP-1", "DP-2"} {
+		a := backend.applied[i]
+		if filepath.Dir(a.path) != cache || len(a.outputs) != 1 || a.outputs[0] != monitor {
+			t.Errorf("backend got %+v, want a slice on %s", a, monitor)
+		}
+	}
+	if backend.applied[0].path == backend.applied[1].path {
+		t.Error("both monitors got the same slice")
+	}
+
+	current, err := db.GetCurrentWallpaperPath("")
+	if err != nil || current != filepath.Join(dir, "wide.png") {
+		t.Errorf("Current() = %s, %v, want the spanned wide.png", current, err)
+	}
+}
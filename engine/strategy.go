@@ -0,0 +1,190 @@
+package engine
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Random strategies.
+const (
+	// StrategyCycle goes through every candidate in a shuffled order before
+	// repeating one.
+	StrategyCycle = "cycle"
+	// StrategyUniform picks any candidate with the same probability.
+	StrategyUniform = "uniform"
+	// StrategyWeighted makes favorites and highly rated candidates more
+	// likely than the others.
+	StrategyWeighted = "weighted"
+	// StrategyLeastRecent picks the candidate that was shown the longest ago,
+	// preferring the ones never shown.
+	StrategyLeastRecent = "least-recent"
+	// StrategyAvoidRecent leaves out the candidates shown in the last
+	// RecentDays. When all of them were, it picks the one shown the longest
+	// ago like StrategyLeastRecent.
+	StrategyAvoidRecent = "avoid-recent"
+)
+
+// Strategies lists the strategies accepted by Random.
+var Strategies = []string{StrategyCycle, StrategyUniform, StrategyWeighted, StrategyLeastRecent, StrategyAvoidRecent}
+
+// favoriteWeight is how many times more likely StrategyWeighted picks a
+// favorite than any other wallpaper.
+const favoriteWeight = 3
+
+// MaxRating is the highest rating of a wallpaper, in stars.
+const MaxRating = 5
+
+// neutralRating is the rating of the wallpapers that are not rated.
+// StrategyWeighted makes a wallpaper rating/neutralRating times as likely.
+const neutralRating = 3
+
+// defaultRecentDays is the window of StrategyAvoidRecent when RecentDays is not set.
+const defaultRecentDays = 7
+
+// Candidate is a wallpaper a Strategy can pick.
+type Candidate struct {
+	Path     string
+	Favorite bool
+	// Rating is from 1 to MaxRating stars, 0 if the wallpaper is not rated.
+	Rating int
+	// LastShown is when the wallpaper was last set on any monitor, zero if
+	// it never was.
+	LastShown time.Time
+}
+
+// Strategy picks a wallpaper for Random. Pick returns the index of the chosen
+// candidate; candidates is never empty.
+type Strategy interface {
+	Pick(candidates []Candidate) (int, error)
+}
+
+// ValidStrategy reports whether name is one of Strategies.
+func ValidStrategy(name string) bool {
+	return slices.Contains(Strategies, name)
+}
+
+// picker returns the Strategy called name. StrategyCycle has no Strategy
+// since it keeps its state in the database.
+func (e *Engine) picker(name string) (Strategy, error) {
+	switch name {
+	case StrategyUniform:
+		return uniform{}, nil
+	case StrategyWeighted:
+		return weighted{}, nil
+	case StrategyLeastRecent:
+		return leastRecent{}, nil
+	case StrategyAvoidRecent:
+		days := e.recentDays
+		if days <= 0 {
+			days = defaultRecentDays
+		}
+		return avoidRecent{now: time.Now(), window: time.Duration(days) * 24 * time.Hour}, nil
+	default:
+		return nil, fmt.Errorf("unknown random strategy %q (expected one of %s)", name, strings.Join(Strategies, ", "))
+	}
+}
+
+type uniform struct{}
+
+func (uniform) Pick(candidates []Candidate) (int, error) {
+	return randomIndex(len(candidates))
+}
+
+type weighted struct{}
+
+func (weighted) Pick(candidates []Candidate) (int, error) {
+	weights := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		rating := candidate.Rating
+		if rating == 0 {
+			rating = neutralRating
+		}
+		weights[i] = float64(rating) / neutralRating
+		if candidate.Favorite {
+			weights[i] *= favoriteWeight
+		}
+	}
+	return weightedIndex(weights)
+}
+
+type leastRecent struct{}
+
+func (leastRecent) Pick(candidates []Candidate) (int, error) {
+	// Collect the candidates sharing the oldest time, so that the ones never
+	// shown are picked at random instead of alphabetically.
+	var oldest []int
+	for i, candidate := range candidates {
+		switch {
+		case len(oldest) == 0 || candidate.LastShown.Before(candidates[oldest[0]].LastShown):
+			oldest = []int{i}
+		case candidate.LastShown.Equal(candidates[oldest[0]].LastShown):
+			oldest = append(oldest, i)
+		}
+	}
+
+	index, err := randomIndex(len(oldest))
+	if err != nil {
+		return 0, err
+	}
+	return oldest[index], nil
+}
+
+type avoidRecent struct {
+	now    time.Time
+	window time.Duration
+}
+
+func (a avoidRecent) Pick(candidates []Candidate) (int, error) {
+	var fresh []int
+	for i, candidate := range candidates {
+		if candidate.LastShown.IsZero() || a.now.Sub(candidate.LastShown) >= a.window {
+			fresh = append(fresh, i)
+		}
+	}
+	if len(fresh) == 0 {
+		return leastRecent{}.Pick(candidates)
+	}
+
+	index, err := randomIndex(len(fresh))
+	if err != nil {
+		return 0, err
+	}
+	return fresh[index], nil
+}
+
+// randomIndex returns a uniformly random index in [0, n).
+func randomIndex(n int) (int, error) {
+	randInt, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(randInt.Int64()), nil
+}
+
+// weightedIndex returns a random index with a probability proportional to its weight.
+func weightedIndex(weights []float64) (int, error) {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	// A million steps is plenty of resolution for the weights used here.
+	const steps = 1_000_000
+	randInt, err := randomIndex(steps)
+	if err != nil {
+		return 0, err
+	}
+	target := float64(randInt) / steps * total
+
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return i, nil
+		}
+	}
+	return len(weights) - 1, nil
+}
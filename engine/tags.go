@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/marcosalvi-01/wallman/db"
+)
+
+// Tags returns the tags of every wallpaper in walls: the ones stored in the
+// database plus, with AutoTags and TravelSubDirs, the names of the
+// subdirectories the wallpaper is in below its wallpaper directory, or below
+// its pool directory when the pool has directories.
+func Tags(walls []string, options Options) (map[string][]string, error) {
+	stored, err := db.GetWallpaperTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]string, len(walls))
+	for _, wall := range walls {
+		wallTags := slices.Clone(stored[wall])
+		if options.AutoTags && options.TravelSubDirs {
+			for _, tag := range dirTags(wallpaperDirs(options), wall) {
+				if !slices.Contains(wallTags, tag) {
+					wallTags = append(wallTags, tag)
+				}
+			}
+		}
+		if len(wallTags) > 0 {
+			slices.Sort(wallTags)
+			tags[wall] = wallTags
+		}
+	}
+	return tags, nil
+}
+
+// HasTags reports whether have contains every tag in want.
+func HasTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// dirTags returns the directories between the wallpaper directory containing
+// path and the file itself, e.g. nature and forest for <dir>/nature/forest/a.png.
+func dirTags(dirs []string, path string) []string {
+	root := ""
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) && len(dir) > len(root) {
+			root = dir
+		}
+	}
+	if root == "" {
+		return nil
+	}
+
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return nil
+	}
+	return strings.Split(rel, string(filepath.Separator))
+}
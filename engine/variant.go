@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+)
+
+// Variants of a wallpaper, named like foo-light.png and foo-dark.png.
+const (
+	VariantLight = "light"
+	VariantDark  = "dark"
+)
+
+// ActionVariant is reported to the hooks when ApplyVariant swaps a wallpaper.
+const ActionVariant = "variant"
+
+// splitVariant returns path without its extension and variant suffix, and
+// the variant, empty when path is not a variant.
+func splitVariant(path string) (stem, variant string) {
+	stem = strings.TrimSuffix(path, filepath.Ext(path))
+	for _, v := range []string{VariantLight, VariantDark} {
+		if trimmed, ok := strings.CutSuffix(stem, "-"+v); ok {
+			return trimmed, v
+		}
+	}
+	return stem, ""
+}
+
+// variantOf returns the sibling of path that is the given variant, whatever
+// its extension.
+func variantOf(path, variant string) (string, bool) {
+	stem, current := splitVariant(path)
+	if current == "" || current == variant {
+		return "", false
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return "", false
+	}
+	want := filepath.Base(stem) + "-" + variant
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && common.IsImage(name) && strings.TrimSuffix(name, filepath.Ext(name)) == want {
+			return filepath.Join(filepath.Dir(path), name), true
+		}
+	}
+	return "", false
+}
+
+// otherVariants returns the wallpapers of walls that are not the variant
+// wanted while their sibling in walls is.
+func otherVariants(walls []string, variant string) map[string]bool {
+	if variant == "" {
+		return nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, wall := range walls {
+		if stem, v := splitVariant(wall); v == variant {
+			wanted[stem] = true
+		}
+	}
+
+	others := make(map[string]bool)
+	for _, wall := range walls {
+		if stem, v := splitVariant(wall); v != "" && v != variant && wanted[stem] {
+			others[wall] = true
+		}
+	}
+	return others
+}
+
+// ApplyVariant swaps the current wallpaper of monitor for its sibling of the
+// variant of the options, e.g. foo-light.png for foo-dark.png at night. It
+// does nothing when there is no such sibling.
+func (e *Engine) ApplyVariant(monitor string) error {
+	if e.variant == "" {
+		return nil
+	}
+
+	targets, err := e.targets(monitor)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		current, err := db.GetCurrentWallpaperPath(target)
+		if err != nil {
+			// Nothing to swap yet.
+			continue
+		}
+		path, ok := variantOf(current, e.variant)
+		if !ok {
+			continue
+		}
+
+		fit := e.resolveFit(path, target, "", FitCover)
+		change := e.begin(ActionVariant, path, target, fit)
+		err = db.SetWallpaper(path, target, fit)
+		if err != nil {
+			return err
+		}
+
+		err = e.apply(path, target, fit)
+		if err != nil {
+			return fmt.Errorf("failed to set %s variant: %w", e.variant, err)
+		}
+		e.finish(change)
+	}
+
+	return nil
+}
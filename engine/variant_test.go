@@ -0,0 +1,89 @@
+package engine_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/engine"
+)
+
+func TestVariants(t *testing.T) {
+	dir := setup(t, "city-dark.png", "city-light.jpg", "forest.png", "moon-dark.png")
+
+	tests := []struct {
+		name    string
+		variant string
+		want    []string
+	}{
+		{"no variant", "", []string{"city-dark.png", "city-light.jpg", "forest.png", "moon-dark.png"}},
+		{"light", engine.VariantLight, []string{"city-light.jpg", "forest.png", "moon-dark.png"}},
+		{"dark", engine.VariantDark, []string{"city-dark.png", "forest.png", "moon-dark.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := engine.New(&fakeBackend{}, engine.Options{WallpaperDirs: []string{dir}, Variant: tt.variant}, nil)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			var got []string
+			for _, path := range e.Candidates() {
+				got = append(got, filepath.Base(path))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyVariant(t *testing.T) {
+	dir := setup(t, "city-dark.png", "city-light.jpg", "moon-dark.png")
+	backend := &fakeBackend{outputs: []string{"DP-1", "HDMI-A-1"}}
+
+	day, err := engine.New(backend, engine.Options{WallpaperDirs: []string{dir}, Variant: engine.VariantLight}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := day.Set(filepath.Join(dir, "city-dark.png"), "DP-1", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := day.Set(filepath.Join(dir, "moon-dark.png"), "HDMI-A-1", ""); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	backend.applied = nil
+	if err := day.ApplyVariant("DP-1"); err != nil {
+		t.Fatalf("ApplyVariant() failed: %v", err)
+	}
+	if err := day.ApplyVariant("HDMI-A-1"); err != nil {
+		t.Fatalf("ApplyVariant() failed: %v", err)
+	}
+
+	// moon has no light variant and is kept.
+	want := []applied{{path: filepath.Join(dir, "city-light.jpg"), fit: engine.FitCover, outputs: []string{"DP-1"}}}
+	if !reflect.DeepEqual(backend.applied, want) {
+		t.Errorf("applied %+v, want %+v", backend.applied, want)
+	}
+	current, err := db.GetCurrentWallpaperPath("DP-1")
+	if err != nil || current != filepath.Join(dir, "city-light.jpg") {
+		t.Errorf("current wallpaper is %q (%v), want the light variant", current, err)
+	}
+
+	// The dark variant is restored the other way round.
+	night, err := engine.New(backend, engine.Options{WallpaperDirs: []string{dir}, Variant: engine.VariantDark}, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := night.ApplyVariant("DP-1"); err != nil {
+		t.Fatalf("ApplyVariant() failed: %v", err)
+	}
+	current, err = db.GetCurrentWallpaperPath("DP-1")
+	if err != nil || current != filepath.Join(dir, "city-dark.png") {
+		t.Errorf("current wallpaper is %q (%v), want the dark variant", current, err)
+	}
+}
@@ -0,0 +1,165 @@
+// Package hyprland follows the workspaces of Hyprland through its event
+// socket, so that every workspace can have its own wallpaper.
+package hyprland
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Events Tracker reacts to.
+const (
+	// EventWorkspace carries the name of the workspace the focused monitor
+	// switched to.
+	EventWorkspace = "workspace"
+	// EventFocusedMon carries the monitor that got the focus and its
+	// workspace, as "monitor,workspace".
+	EventFocusedMon = "focusedmon"
+)
+
+var (
+	// ErrNotRunning is returned by SocketPath outside of a Hyprland session.
+	ErrNotRunning = errors.New("not running under Hyprland: HYPRLAND_INSTANCE_SIGNATURE is not set")
+	// ErrClosed is returned by Listen when Hyprland closes the event socket.
+	ErrClosed = errors.New("the Hyprland event socket was closed")
+)
+
+// SocketPath returns the event socket of the Hyprland instance of the
+// session: $XDG_RUNTIME_DIR/hypr/$HYPRLAND_INSTANCE_SIGNATURE/.socket2.sock,
+// or the /tmp/hypr one of Hyprland before 0.40 when only that one exists.
+func SocketPath() (string, error) {
+	signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
+	if signature == "" {
+		return "", ErrNotRunning
+	}
+
+	path := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "hypr", signature, ".socket2.sock")
+	legacy := filepath.Join("/tmp", "hypr", signature, ".socket2.sock")
+	if _, err := os.Stat(path); err != nil {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+	return path, nil
+}
+
+// Event is a line of the event socket, "name>>data".
+type Event struct {
+	Name string
+	Data string
+}
+
+// ParseEvent parses a line of the event socket, reporting false when it is
+// not an event.
+func ParseEvent(line string) (Event, bool) {
+	name, data, ok := strings.Cut(line, ">>")
+	if !ok || name == "" {
+		return Event{}, false
+	}
+	return Event{Name: name, Data: data}, true
+}
+
+// Listen reads the events of the socket at path and hands them to handle,
+// in order, until ctx is done. It returns ErrClosed when the socket is
+// closed on the other end.
+func Listen(ctx context.Context, path string, handle func(Event)) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the Hyprland event socket: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if event, ok := ParseEvent(scanner.Text()); ok {
+			handle(event)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read the Hyprland event socket: %w", err)
+	}
+	return ErrClosed
+}
+
+// Focus is a workspace shown on a monitor.
+type Focus struct {
+	Monitor   string
+	Workspace string
+}
+
+// ActiveWorkspace returns the focused monitor and its workspace, as
+// `hyprctl activeworkspace -j` reports them.
+func ActiveWorkspace() (Focus, error) {
+	out, err := exec.Command("hyprctl", "activeworkspace", "-j").Output()
+	if err != nil {
+		return Focus{}, fmt.Errorf("failed to get the active workspace: %w", err)
+	}
+	return parseActiveWorkspace(out)
+}
+
+func parseActiveWorkspace(out []byte) (Focus, error) {
+	var workspace struct {
+		Name    string `json:"name"`
+		Monitor string `json:"monitor"`
+	}
+	if err := json.Unmarshal(out, &workspace); err != nil {
+		return Focus{}, fmt.Errorf("failed to parse active workspace JSON: %w", err)
+	}
+	return Focus{Monitor: workspace.Monitor, Workspace: workspace.Name}, nil
+}
+
+// Tracker follows the workspace shown on every monitor through the events.
+type Tracker struct {
+	focused string
+	shown   map[string]string
+}
+
+// NewTracker returns a Tracker starting from the focused workspace. An empty
+// monitor stands for an unknown one until a focusedmon event names it.
+func NewTracker(focused Focus) *Tracker {
+	t := &Tracker{focused: focused.Monitor, shown: make(map[string]string)}
+	if focused.Workspace != "" {
+		t.shown[focused.Monitor] = focused.Workspace
+	}
+	return t
+}
+
+// Update returns the workspace event brought onto a monitor. It reports
+// false for other events and when the monitor already showed the workspace,
+// e.g. when the focus only moves to another monitor.
+func (t *Tracker) Update(event Event) (Focus, bool) {
+	var focus Focus
+	switch event.Name {
+	case EventWorkspace:
+		focus = Focus{Monitor: t.focused, Workspace: event.Data}
+	case EventFocusedMon:
+		monitor, workspace, ok := strings.Cut(event.Data, ",")
+		if !ok {
+			return Focus{}, false
+		}
+		t.focused = monitor
+		focus = Focus{Monitor: monitor, Workspace: workspace}
+	default:
+		return Focus{}, false
+	}
+
+	if focus.Workspace == "" || t.shown[focus.Monitor] == focus.Workspace {
+		return focus, false
+	}
+	t.shown[focus.Monitor] = focus.Workspace
+	return focus, true
+}
@@ -0,0 +1,159 @@
+package hyprland
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recorded is the event stream of switching from workspace 1 to 2 on DP-1,
+// then focusing HDMI-A-1 showing workspace 3, as Hyprland sends it.
+var recorded = []string{
+	"activewindow>>kitty,~",
+	"activewindowv2>>55d7e4c0a9f0",
+	"workspace>>2",
+	"workspacev2>>2,2",
+	"activewindow>>,",
+	"focusedmon>>HDMI-A-1,3",
+	"focusedmonv2>>HDMI-A-1,3",
+	"openwindow>>55d7e4c0b1a0,3,firefox,Mozilla Firefox",
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   Event
+		wantOk bool
+	}{
+		{"workspace>>2", Event{Name: "workspace", Data: "2"}, true},
+		{"focusedmon>>DP-1,web", Event{Name: "focusedmon", Data: "DP-1,web"}, true},
+		{"activewindow>>kitty,a>>b", Event{Name: "activewindow", Data: "kitty,a>>b"}, true},
+		{"configreloaded>>", Event{Name: "configreloaded"}, true},
+		{"garbage", Event{}, false},
+		{">>data", Event{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseEvent(tt.line)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("ParseEvent(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(Focus{Monitor: "DP-1", Workspace: "1"})
+
+	steps := []struct {
+		event  Event
+		want   Focus
+		wantOk bool
+	}{
+		{Event{Name: "workspace", Data: "1"}, Focus{Monitor: "DP-1", Workspace: "1"}, false},
+		{Event{Name: "workspace", Data: "2"}, Focus{Monitor: "DP-1", Workspace: "2"}, true},
+		{Event{Name: "activewindow", Data: "kitty,~"}, Focus{}, false},
+		{Event{Name: "focusedmon", Data: "HDMI-A-1,3"}, Focus{Monitor: "HDMI-A-1", Workspace: "3"}, true},
+		{Event{Name: "workspace", Data: "4"}, Focus{Monitor: "HDMI-A-1", Workspace: "4"}, true},
+		// Focusing a monitor again does not change its workspace.
+		{Event{Name: "focusedmon", Data: "DP-1,2"}, Focus{Monitor: "DP-1", Workspace: "2"}, false},
+		{Event{Name: "focusedmon", Data: "broken"}, Focus{}, false},
+	}
+	for i, step := range steps {
+		got, ok := tracker.Update(step.event)
+		if ok != step.wantOk || got != step.want {
+			t.Errorf("step %d: Update(%+v) = %+v, %v, want %+v, %v", i, step.event, got, ok, step.want, step.wantOk)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".socket2.sock")
+	replay, err := NewReplay(path, recorded)
+	if err != nil {
+		t.Fatalf("NewReplay() failed: %v", err)
+	}
+
+	events := make(chan Event, len(recorded))
+	done := make(chan error, 1)
+	go func() { done <- Listen(context.Background(), path, func(e Event) { events <- e }) }()
+
+	var got []string
+	for range recorded {
+		select {
+		case e := <-events:
+			got = append(got, e.Name+">>"+e.Data)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d events, want %d", len(got), len(recorded))
+		}
+	}
+	if !reflect.DeepEqual(got, recorded) {
+		t.Errorf("events = %v, want %v", got, recorded)
+	}
+
+	if err := replay.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("Listen() = %v, want ErrClosed", err)
+	}
+}
+
+func TestListenCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".socket2.sock")
+	replay, err := NewReplay(path, nil)
+	if err != nil {
+		t.Fatalf("NewReplay() failed: %v", err)
+	}
+	defer replay.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Listen(ctx, path, func(Event) {}); err != nil {
+		t.Errorf("Listen() = %v, want nil once ctx is done", err)
+	}
+}
+
+func TestListenNoSocket(t *testing.T) {
+	err := Listen(context.Background(), filepath.Join(t.TempDir(), ".socket2.sock"), func(Event) {})
+	if err == nil {
+		t.Error("Listen() succeeded without a socket")
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+	if _, err := SocketPath(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("SocketPath() = %v, want ErrNotRunning", err)
+	}
+
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "abc_123")
+	want := filepath.Join(runtime, "hypr", "abc_123", ".socket2.sock")
+	if err := os.MkdirAll(filepath.Dir(want), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	got, err := SocketPath()
+	if err != nil || got != want {
+		t.Errorf("SocketPath() = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestParseActiveWorkspace(t *testing.T) {
+	out := []byte(`{"id": 2, "name": "web", "monitor": "DP-1", "monitorID": 0, "windows": 1, "hasfullscreen": false}`)
+	got, err := parseActiveWorkspace(out)
+	if err != nil {
+		t.Fatalf("parseActiveWorkspace() failed: %v", err)
+	}
+	if want := (Focus{Monitor: "DP-1", Workspace: "web"}); got != want {
+		t.Errorf("parseActiveWorkspace() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseActiveWorkspace([]byte("not json")); err == nil {
+		t.Error("parseActiveWorkspace() succeeded on invalid JSON")
+	}
+}
@@ -0,0 +1,62 @@
+package hyprland
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Replay stands in for the event socket of Hyprland in tests: every client
+// connecting to it gets the recorded events, then the connection stays open
+// until Close.
+type Replay struct {
+	listener net.Listener
+	events   []string
+
+	mu    sync.Mutex
+	conns []net.Conn
+	done  chan struct{}
+}
+
+// NewReplay listens on a unix socket at path and replays events, lines
+// without their newline, to the clients.
+func NewReplay(path string, events []string) (*Replay, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	r := &Replay{listener: listener, events: events, done: make(chan struct{})}
+	go r.serve()
+	return r, nil
+}
+
+func (r *Replay) serve() {
+	defer close(r.done)
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.conns = append(r.conns, conn)
+		r.mu.Unlock()
+		for _, event := range r.events {
+			if _, err := fmt.Fprintln(conn, event); err != nil {
+				break
+			}
+		}
+	}
+}
+
+// Close stops listening and closes the connections, which clients see as
+// Hyprland going away.
+func (r *Replay) Close() error {
+	err := r.listener.Close()
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+	return err
+}
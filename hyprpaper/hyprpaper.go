@@ -2,306 +2,74 @@
 package hyprpaper
 
 import (
-	crand "crypto/rand"
 	"encoding/json"
 	"fmt"
-	"io/fs"
-	"log"
-	"math/big"
-	"os"
 	"os/exec"
-	"path"
-	"path/filepath"
-	"slices"
-	"strings"
 
-	"github.com/marcosalvi-01/wallman/cmd/common"
-	"github.com/marcosalvi-01/wallman/db"
-	"github.com/marcosalvi-01/wallman/db/sqlc"
+	"github.com/marcosalvi-01/wallman/engine"
 )
 
-var configDir = filepath.Join(os.Getenv("HOME"), ".local", "share", "wallman")
+// Hyprpaper is an engine.Backend talking to hyprpaper through hyprctl.
+type Hyprpaper struct{}
 
-type Hyprpaper struct {
-	configDir     string
-	wallpaperDirs []string
-	wallpapers    []string
-	queries       *sqlc.Queries
-	dryRun        bool
+func New() *Hyprpaper {
+	return &Hyprpaper{}
 }
 
-func New(wallpaperDirs []string, travelSubdirs bool, queries *sqlc.Queries, dryRun bool) (*Hyprpaper, error) {
-	walls := make([]string, 0)
-
-	for _, wallpaperDir := range wallpaperDirs {
-		if travelSubdirs {
-			if err := filepath.WalkDir(wallpaperDir, func(dirPath string, d fs.DirEntry, err error) error {
-				if err != nil {
-					log.Printf("warning: failed to access %s: %v", dirPath, err)
-					return nil
-				}
-				if d.IsDir() || !common.IsImage(d.Name()) {
-					return nil
-				}
-				walls = append(walls, dirPath)
-				return nil
-			}); err != nil {
-				return nil, fmt.Errorf("failed to walk directory %s: %w", wallpaperDir, err)
-			}
-		} else {
-			entries, err := os.ReadDir(wallpaperDir)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read wallpaper directory: %w", err)
-			}
-
-			for _, entry := range entries {
-				if entry.IsDir() || !common.IsImage(entry.Name()) {
-					continue
-				}
-				walls = append(walls, path.Join(wallpaperDir, entry.Name()))
-			}
-		}
-	}
-
-	if _, err := os.Stat(configDir); err != nil {
-		if os.IsNotExist(err) {
-			err := os.MkdirAll(configDir, 0o700)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create config directory: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to access config directory: %w", err)
-		}
-	}
-
-	randWalls := make([]string, len(walls))
-	copy(randWalls, walls)
-
-	common.ShuffleSlice(randWalls)
-
-	randomFile := path.Join(configDir, "random")
-
-	if _, err := os.Stat(randomFile); err != nil {
-		if os.IsNotExist(err) {
-			join := strings.Join(randWalls, "\n") + "\n"
-
-			err := os.WriteFile(randomFile, []byte(join), 0o600)
-			if err != nil {
-				return nil, fmt.Errorf("failed to write random wallpaper file: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to access config directory: %w", err)
-		}
-	}
-
-	return &Hyprpaper{
-		configDir:     configDir,
-		wallpaperDirs: wallpaperDirs,
-		wallpapers:    walls,
-		queries:       queries,
-		dryRun:        dryRun,
-	}, nil
-}
-
-func (h *Hyprpaper) Next() error {
-	if len(h.wallpapers) == 0 {
-		return fmt.Errorf("no wallpapers available")
-	}
-
-	current, err := db.GetCurrentWallpaperPath()
-	index := -1
-	if err == nil {
-		for i, w := range h.wallpapers {
-			if w == current {
-				index = i
-				break
-			}
-		}
-	}
-
-	nextIndex := 0
-	if index != -1 {
-		nextIndex = (index + 1) % len(h.wallpapers)
+func (h *Hyprpaper) Apply(path, fit string, outputs []string) error {
+	if len(outputs) == 0 {
+		return setWallpaperToAllMonitors(path, fit)
 	}
-
-	path := h.wallpapers[nextIndex]
-
-	err = db.SetWallpaper(path)
-	if err != nil {
-		return err
-	}
-
-	if !h.dryRun {
-		err := setWallpaperToAllMonitors(path, "cover")
+	for _, monitor := range outputs {
+		err := setWallpaper(path, monitor, fit)
 		if err != nil {
-			return fmt.Errorf("failed to set next wallpaper on all monitors: %w", err)
+			return fmt.Errorf("failed to set wallpaper on monitor %s: %w", monitor, err)
 		}
 	}
-
 	return nil
 }
 
-func (h *Hyprpaper) Previous() error {
-	path, setAt, err := db.GetPreviousWallpaper()
-	if err != nil {
-		return err
-	}
-
-	err = db.SetCurrentWallpaper(path, setAt)
-	if err != nil {
-		return err
-	}
-
-	if !h.dryRun {
-		err := setWallpaperToAllMonitors(path, "cover")
-		if err != nil {
-			return fmt.Errorf("failed to set previous wallpaper on all monitors: %w", err)
-		}
-	}
-
-	return nil
+func (h *Hyprpaper) Outputs() ([]string, error) {
+	return listMonitors()
 }
 
-func (h *Hyprpaper) Random(trueRandom bool) error {
-	if len(h.wallpapers) == 0 {
-		return fmt.Errorf("no wallpapers available")
-	}
-
-	if trueRandom {
-		// Old behavior: pick completely random from all wallpapers
-		bigInt := big.NewInt(int64(len(h.wallpapers)))
-		randInt, randErr := crand.Int(crand.Reader, bigInt)
-		if randErr != nil {
-			return randErr
-		}
-		randomIndex := int(randInt.Int64())
-		path := h.wallpapers[randomIndex]
-
-		err := db.SetWallpaper(path)
-		if err != nil {
-			return err
-		}
-
-		if !h.dryRun {
-			err := setWallpaperToAllMonitors(path, "fill")
-			if err != nil {
-				return fmt.Errorf("failed to set random wallpaper on all monitors: %w", err)
-			}
-		}
-
-		return nil
-	}
-
-	// Cycle behavior
-	shuffled, index, err := db.GetRandomCycle()
-	if err != nil {
-		// If no cycle, initialize it
-		shuffled = make([]string, len(h.wallpapers))
-		copy(shuffled, h.wallpapers)
-		common.ShuffleSlice(shuffled)
-		index = 0
-		err = db.UpsertRandomCycle(shuffled, index)
-		if err != nil {
-			return fmt.Errorf("failed to initialize random cycle: %w", err)
-		}
-	}
-
-	// Verify the current shuffled matches h.wallpapers (handle changes)
-	valid := len(shuffled) == len(h.wallpapers)
-	if valid {
-		for _, s := range shuffled {
-			found := slices.Contains(h.wallpapers, s)
-			if !found {
-				valid = false
-				break
-			}
-		}
-	}
-	if !valid {
-		// Reset cycle
-		shuffled = make([]string, len(h.wallpapers))
-		copy(shuffled, h.wallpapers)
-		common.ShuffleSlice(shuffled)
-		index = 0
-		err = db.UpsertRandomCycle(shuffled, index)
-		if err != nil {
-			return fmt.Errorf("failed to reset random cycle: %w", err)
-		}
-	}
-
-	path := shuffled[index]
-	err = db.SetWallpaper(path)
+// Geometry returns the size of every monitor as hyprctl reports it.
+func (h *Hyprpaper) Geometry() (map[string]engine.Geometry, error) {
+	monitors, err := queryMonitors()
 	if err != nil {
-		return err
-	}
-
-	if !h.dryRun {
-		err := setWallpaperToAllMonitors(path, "cover")
-		if err != nil {
-			return fmt.Errorf("failed to set random wallpaper on all monitors: %w", err)
-		}
+		return nil, err
 	}
 
-	// Advance index
-	index++
-	if index >= len(shuffled) {
-		// Cycle complete, reshuffle for next
-		common.ShuffleSlice(shuffled)
-		index = 0
-	}
-	err = db.UpsertRandomCycle(shuffled, index)
-	if err != nil {
-		return fmt.Errorf("failed to update random cycle: %w", err)
+	geometry := make(map[string]engine.Geometry, len(monitors))
+	for _, m := range monitors {
+		geometry[m.Name] = m.geometry()
 	}
-
-	return nil
+	return geometry, nil
 }
 
-func (h *Hyprpaper) Current() (string, error) {
-	return db.GetCurrentWallpaperPath()
+// monitor is an entry of `hyprctl monitors -j`.
+type monitor struct {
+	Name string `json:"name"`
+	// Width and Height are the pixels of the current mode, before the
+	// transform and independent of the scale.
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+	Scale     float64 `json:"scale"`
+	Transform int     `json:"transform"`
 }
 
-func (h *Hyprpaper) History() ([]string, error) {
-	history, err := db.GetWallpaperHistory(100)
-	if err != nil {
-		return nil, err
-	}
-	paths := make([]string, len(history))
-	for i, h := range history {
-		paths[i] = h.Path
+// geometry returns the size of the monitor as seen by a wallpaper and its
+// place in the layout. hyprpaper draws wallpapers at the native resolution,
+// the scale only matters for the layout.
+func (m monitor) geometry() engine.Geometry {
+	geometry := engine.Geometry{Width: m.Width, Height: m.Height, X: m.X, Y: m.Y, Scale: m.Scale}
+	// Odd transforms rotate the output by 90 or 270 degrees.
+	if m.Transform%2 == 1 {
+		geometry.Width, geometry.Height = m.Height, m.Width
 	}
-	return paths, nil
-}
-
-func (h *Hyprpaper) Set(path string) error {
-	path = common.ExpandPath(path)
-
-	info, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("failed to access wallpaper file: %w", err)
-	}
-
-	if !info.Mode().IsRegular() {
-		return fmt.Errorf("path is not a regular file")
-	}
-
-	if !common.IsImage(filepath.Base(path)) {
-		return fmt.Errorf("unsupported image format (only JPEG and PNG are supported)")
-	}
-
-	err = db.SetWallpaper(path)
-	if err != nil {
-		return fmt.Errorf("failed to set wallpaper in database: %w", err)
-	}
-
-	if !h.dryRun {
-		err := setWallpaperToAllMonitors(path, "fill")
-		if err != nil {
-			return fmt.Errorf("failed to set wallpaper on monitors: %w", err)
-		}
-	}
-
-	return nil
+	return geometry
 }
 
 func setWallpaperToAllMonitors(path, fit string) error {
@@ -328,22 +96,31 @@ func setWallpaper(path, monitor, fit string) error {
 }
 
 func listMonitors() ([]string, error) {
+	monitors, err := queryMonitors()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range monitors {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+func queryMonitors() ([]monitor, error) {
 	cmd := exec.Command("hyprctl", "monitors", "-j")
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monitors: %v", err)
 	}
+	return parseMonitors(out)
+}
 
-	var data []struct {
-		Name string `json:"name"`
-	}
-	if err := json.Unmarshal(out, &data); err != nil {
+func parseMonitors(out []byte) ([]monitor, error) {
+	var monitors []monitor
+	if err := json.Unmarshal(out, &monitors); err != nil {
 		return nil, fmt.Errorf("failed to parse monitors JSON: %v", err)
 	}
-
-	var names []string
-	for _, m := range data {
-		names = append(names, m.Name)
-	}
-	return names, nil
+	return monitors, nil
 }
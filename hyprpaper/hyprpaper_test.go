@@ -0,0 +1,40 @@
+package hyprpaper
+
+import (
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/engine"
+)
+
+func TestParseMonitors(t *testing.T) {
+	out := []byte(`[
+		{"id": 0, "name": "DP-1", "width": 2560, "height": 1440, "x": 0, "y": 0, "scale": 1.25, "transform": 0},
+		{"id": 1, "name": "HDMI-A-1", "width": 1920, "height": 1080, "x": 2048, "y": 0, "scale": 1.00, "transform": 1},
+		{"id": 2, "name": "eDP-1", "width": 1920, "height": 1200, "x": 0, "y": 1152, "scale": 1.50, "transform": 6}
+	]`)
+
+	monitors, err := parseMonitors(out)
+	if err != nil {
+		t.Fatalf("parseMonitors() failed: %v", err)
+	}
+
+	want := map[string]engine.Geometry{
+		"DP-1":     {Width: 2560, Height: 1440, Scale: 1.25},
+		"HDMI-A-1": {Width: 1080, Height: 1920, X: 2048, Scale: 1},
+		"eDP-1":    {Width: 1920, Height: 1200, Y: 1152, Scale: 1.5},
+	}
+	if len(monitors) != len(want) {
+		t.Fatalf("parsed %d monitors, want %d", len(monitors), len(want))
+	}
+	for _, m := range monitors {
+		if got := m.geometry(); got != want[m.Name] {
+			t.Errorf("geometry of %s = %+v, want %+v", m.Name, got, want[m.Name])
+		}
+	}
+}
+
+func TestParseMonitorsInvalid(t *testing.T) {
+	if _, err := parseMonitors([]byte("not json")); err == nil {
+		t.Error("parseMonitors() accepted invalid JSON")
+	}
+}
@@ -0,0 +1,60 @@
+// Package testutil holds the fixtures shared by the tests of several
+// packages.
+package testutil
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+)
+
+// Home points HOME to an empty directory for the rest of the test and closes
+// the database, so that the test gets a database of its own. It returns the
+// directory.
+func Home(t testing.TB) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	closeDB := func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close the database: %v", err)
+		}
+	}
+	closeDB()
+	// Cleanups run last first, so the database is closed before the
+	// directory is removed.
+	t.Cleanup(closeDB)
+	return home
+}
+
+// WritePNG encodes img as a PNG at path.
+func WritePNG(t testing.TB, path string, img image.Image) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode %s: %v", path, err)
+	}
+}
+
+// WriteImage writes a width x height PNG of color c at path.
+func WriteImage(t testing.TB, path string, width, height int, c color.Color) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+	WritePNG(t, path, img)
+}
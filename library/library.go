@@ -0,0 +1,210 @@
+// Package library keeps an index of the wallpapers found in the configured
+// directories in the database, so that commands read the index instead of
+// walking the directories every time. Files are identified by their content
+// and corrupt ones are left out. The index is refreshed incrementally: only
+// files whose size or modification time changed are read again.
+package library
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/db/sqlc"
+)
+
+// Options select the indexed directories.
+type Options struct {
+	Dirs      []string
+	Recursive bool
+	// MaxAge is how old the index of a directory may get before Load scans it
+	// again. Zero scans every time, a negative value only scans directories
+	// that were never indexed.
+	MaxAge time.Duration
+}
+
+// Result counts the changes made by a scan.
+type Result struct {
+	Total   int
+	Added   int
+	Updated int
+	Removed int
+}
+
+// Scan indexes every directory of options. With full, the files are read again
+// even if they did not change.
+func Scan(options Options, full bool) (Result, error) {
+	var total Result
+	for _, dir := range options.Dirs {
+		result, _, err := scanDir(dir, options.Recursive, full)
+		if err != nil {
+			return total, err
+		}
+		total.Total += result.Total
+		total.Added += result.Added
+		total.Updated += result.Updated
+		total.Removed += result.Removed
+	}
+	return total, nil
+}
+
+// Load returns the indexed wallpapers, first scanning the directories whose
+// index is missing or older than MaxAge.
+func Load(options Options) ([]sqlc.Wallpaper, error) {
+	var walls []sqlc.Wallpaper
+	for _, dir := range options.Dirs {
+		stale, err := isStale(dir, options)
+		if err != nil {
+			return nil, err
+		}
+		if stale {
+			_, _, err = scanDir(dir, options.Recursive, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		indexed, err := db.GetLibrary(dir)
+		if err != nil {
+			return nil, err
+		}
+		walls = append(walls, indexed...)
+	}
+	return walls, nil
+}
+
+// List returns the paths of the indexed wallpapers, like Load.
+func List(options Options) ([]string, error) {
+	walls, err := Load(options)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(walls))
+	for i, wall := range walls {
+		paths[i] = wall.Path
+	}
+	return paths, nil
+}
+
+func isStale(dir string, options Options) (bool, error) {
+	scan, found, err := db.GetLibraryScan(dir)
+	if err != nil {
+		return false, err
+	}
+	if !found || scan.Recursive != options.Recursive {
+		return true, nil
+	}
+	if options.MaxAge < 0 {
+		return false, nil
+	}
+	return time.Since(scan.ScannedAt) >= options.MaxAge, nil
+}
+
+// scanDir brings the index of root up to date with the filesystem. It also
+// returns the paths removed from the index.
+func scanDir(root string, recursive, full bool) (Result, []string, error) {
+	started := time.Now()
+
+	indexed, err := db.GetLibrary(root)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	known := make(map[string]sqlc.Wallpaper, len(indexed))
+	for _, wall := range indexed {
+		known[wall.Path] = wall
+	}
+
+	var result Result
+	var changed []sqlc.Wallpaper
+	seen := make(map[string]bool, len(indexed))
+	visit := func(path string, entry fs.DirEntry) {
+		info, err := fileInfo(path, entry)
+		if err != nil || !info.Mode().IsRegular() {
+			return
+		}
+
+		old, ok := known[path]
+		if ok && !full && old.Size == info.Size() && old.Mtime.Equal(info.ModTime()) {
+			seen[path] = true
+			result.Total++
+			return
+		}
+
+		image, err := common.Sniff(path)
+		if err != nil {
+			// Not an image after all, or a corrupt one: leave it out.
+			return
+		}
+		seen[path] = true
+		result.Total++
+		if ok {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+
+		changed = append(changed, sqlc.Wallpaper{
+			Root:   root,
+			Path:   path,
+			Size:   info.Size(),
+			Mtime:  info.ModTime(),
+			Width:  int64(image.Width),
+			Height: int64(image.Height),
+			Format: image.Format,
+		})
+	}
+
+	if recursive {
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() || !common.IsImage(d.Name()) {
+				return nil
+			}
+			visit(path, d)
+			return nil
+		})
+		if err != nil {
+			return Result{}, nil, fmt.Errorf("failed to walk directory %s: %w", root, err)
+		}
+	} else {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return Result{}, nil, fmt.Errorf("failed to read wallpaper directory %s: %w", root, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !common.IsImage(entry.Name()) {
+				continue
+			}
+			visit(filepath.Join(root, entry.Name()), entry)
+		}
+	}
+
+	var removed []string
+	for _, wall := range indexed {
+		if !seen[wall.Path] {
+			removed = append(removed, wall.Path)
+		}
+	}
+	result.Removed = len(removed)
+
+	err = db.UpdateLibrary(root, recursive, changed, removed, started)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	return result, removed, nil
+}
+
+// fileInfo returns the info of the file behind entry, following symlinks.
+func fileInfo(path string, entry fs.DirEntry) (fs.FileInfo, error) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		return os.Stat(path)
+	}
+	return entry.Info()
+}
@@ -0,0 +1,98 @@
+package library_test
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/library"
+)
+
+func TestScan(t *testing.T) {
+	home := testutil.Home(t)
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	testutil.WriteImage(t, filepath.Join(dir, "a.png"), 4, 3, color.Black)
+	testutil.WriteImage(t, filepath.Join(dir, "b.png"), 2, 2, color.Black)
+	options := library.Options{Dirs: []string{dir}}
+
+	result, err := library.Scan(options, false)
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	if result != (library.Result{Total: 2, Added: 2}) {
+		t.Errorf("first Scan() = %+v, want 2 added", result)
+	}
+
+	testutil.WriteImage(t, filepath.Join(dir, "b.png"), 8, 6, color.Black)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "b.png"), later, later); err != nil {
+		t.Fatalf("Failed to touch b.png: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "a.png")); err != nil {
+		t.Fatalf("Failed to remove a.png: %v", err)
+	}
+
+	result, err = library.Scan(options, false)
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	if result != (library.Result{Total: 1, Updated: 1, Removed: 1}) {
+		t.Errorf("second Scan() = %+v, want 1 updated and 1 removed", result)
+	}
+
+	walls, err := library.Load(options)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(walls) != 1 || walls[0].Width != 8 || walls[0].Height != 6 {
+		t.Errorf("Load() = %+v, want b.png at 8x6", walls)
+	}
+}
+
+func TestLoadRescans(t *testing.T) {
+	home := testutil.Home(t)
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	testutil.WriteImage(t, filepath.Join(dir, "a.png"), 1, 1, color.Black)
+
+	// The first load always indexes the directory.
+	never := library.Options{Dirs: []string{dir}, MaxAge: -1}
+	if got, err := library.List(never); err != nil || len(got) != 1 {
+		t.Fatalf("List() = %v, %v, want a.png", got, err)
+	}
+
+	testutil.WriteImage(t, filepath.Join(dir, "b.png"), 1, 1, color.Black)
+
+	tests := []struct {
+		name    string
+		options library.Options
+		want    []string
+	}{
+		{"fresh index", never, []string{"a.png"}},
+		{"stale index", library.Options{Dirs: []string{dir}}, []string{"a.png", "b.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := library.List(tt.options)
+			if err != nil {
+				t.Fatalf("List() failed: %v", err)
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(dir, name))
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("List() = %v, want %v", got, want)
+			}
+		})
+	}
+}
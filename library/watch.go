@@ -0,0 +1,152 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/cmd/common"
+)
+
+type op int
+
+const (
+	opWrite op = iota
+	opRemove
+	opMovedFrom
+	opMovedTo
+	// opOverflow means events were lost and everything has to be rescanned.
+	opOverflow
+)
+
+// event is a filesystem change below one of the watched directories.
+type event struct {
+	root string
+	path string
+	op   op
+	dir  bool
+	// cookie pairs the opMovedFrom and opMovedTo events of a rename.
+	cookie uint32
+}
+
+// Change is what a rescan triggered by Watch found.
+type Change struct {
+	Result Result
+	// Removed lists the wallpapers that no longer exist.
+	Removed []string
+	// Renamed maps the old path of every moved wallpaper to its new path.
+	Renamed map[string]string
+}
+
+// debounce is how long Watch waits for more events before rescanning, so
+// that copying many files results in a single rescan.
+const debounce = 500 * time.Millisecond
+
+// Watch keeps the index of the directories of options up to date until ctx is
+// done. After every rescan that changed something, handle is called so that
+// the state built on top of the index can follow. Each rescan runs together
+// with its handle call through run, which lets callers serialize them with
+// their own database accesses; nil runs them directly. Errors of a rescan or
+// of handle are logged and do not stop the watch.
+func Watch(ctx context.Context, options Options, run func(func() error) error, handle func(Change) error) error {
+	if run == nil {
+		run = func(fn func() error) error { return fn() }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan event, 256)
+	errc := make(chan error, 1)
+	go func() { errc <- watchDirs(ctx, options.Dirs, options.Recursive, events) }()
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	dirty := make(map[string]bool)
+	movedFrom := make(map[uint32]string)
+	renamed := make(map[string]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errc:
+			return err
+
+		case ev := <-events:
+			switch ev.op {
+			case opOverflow:
+				for _, dir := range options.Dirs {
+					dirty[dir] = true
+				}
+			case opMovedFrom:
+				if !ev.dir {
+					movedFrom[ev.cookie] = ev.path
+				}
+			case opMovedTo:
+				if old, ok := movedFrom[ev.cookie]; ok && !ev.dir {
+					renamed[old] = ev.path
+					delete(movedFrom, ev.cookie)
+				}
+			}
+			if ev.root != "" {
+				dirty[ev.root] = true
+			}
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			err := run(func() error {
+				change, err := rescan(options, dirty, renamed)
+				if err != nil {
+					return fmt.Errorf("rescan failed: %w", err)
+				}
+				if change.Result.Added+change.Result.Updated+change.Result.Removed == 0 {
+					return nil
+				}
+				return handle(change)
+			})
+			if err != nil {
+				log.Printf("failed to update the library: %v", err)
+			}
+			clear(dirty)
+			clear(movedFrom)
+			clear(renamed)
+		}
+	}
+}
+
+// rescan scans the dirty directories. Renames are only reported when the old
+// path left the index and the new one is a wallpaper.
+func rescan(options Options, dirty map[string]bool, renamed map[string]string) (Change, error) {
+	var change Change
+	for _, dir := range options.Dirs {
+		if !dirty[dir] {
+			continue
+		}
+		result, removed, err := scanDir(dir, options.Recursive, false)
+		if err != nil {
+			return Change{}, err
+		}
+		change.Result.Total += result.Total
+		change.Result.Added += result.Added
+		change.Result.Updated += result.Updated
+		change.Result.Removed += result.Removed
+		change.Removed = append(change.Removed, removed...)
+	}
+
+	change.Renamed = make(map[string]string)
+	for old, path := range renamed {
+		if _, err := common.Sniff(path); err == nil && slices.Contains(change.Removed, old) {
+			change.Renamed[old] = path
+		}
+	}
+	change.Removed = slices.DeleteFunc(change.Removed, func(path string) bool {
+		_, ok := change.Renamed[path]
+		return ok
+	})
+	return change, nil
+}
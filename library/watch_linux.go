@@ -0,0 +1,162 @@
+//go:build linux
+
+package library
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fileEvents = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO |
+		syscall.IN_MOVED_FROM | syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+	// The buffer holds at least one event with the longest possible name.
+	eventBufferSize = 64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)
+)
+
+// inotify watches the wallpaper directories with the inotify API.
+type inotify struct {
+	file      *os.File
+	fd        int
+	recursive bool
+	// dirs maps watch descriptors to the watched directory and its root.
+	dirs map[int32]watchedDir
+}
+
+type watchedDir struct {
+	path string
+	root string
+}
+
+func watchDirs(ctx context.Context, roots []string, recursive bool, events chan<- event) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	// A non-blocking descriptor is handled by the runtime poller, so closing
+	// the file interrupts a pending read.
+	w := &inotify{
+		file:      os.NewFile(uintptr(fd), "inotify"),
+		fd:        fd,
+		recursive: recursive,
+		dirs:      make(map[int32]watchedDir),
+	}
+
+	for _, root := range roots {
+		err = w.addTree(root, root)
+		if err != nil {
+			_ = w.file.Close()
+			return err
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = w.file.Close()
+	}()
+
+	return w.read(ctx, events)
+}
+
+// addTree watches dir and, when recursive, the directories below it.
+func (w *inotify) addTree(dir, root string) error {
+	if !w.recursive {
+		return w.add(dir, root)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		return w.add(path, root)
+	})
+}
+
+func (w *inotify) add(dir, root string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, fileEvents)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	w.dirs[int32(wd)] = watchedDir{path: dir, root: root}
+	return nil
+}
+
+func (w *inotify) read(ctx context.Context, events chan<- event) error {
+	buf := make([]byte, eventBufferSize)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to read inotify events: %w", err)
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buf[nameStart:nameStart+int(raw.Len)], "\x00"))
+			offset = nameStart + int(raw.Len)
+
+			ev, ok := w.translate(raw, name)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// translate turns a raw inotify event into an event, watching new
+// directories on the way.
+func (w *inotify) translate(raw *syscall.InotifyEvent, name string) (event, bool) {
+	if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		return event{op: opOverflow}, true
+	}
+
+	dir, ok := w.dirs[raw.Wd]
+	if !ok {
+		return event{}, false
+	}
+	if raw.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, raw.Wd)
+		return event{}, false
+	}
+	if raw.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		// The parent reports the change, or for a root the rescan will.
+		return event{root: dir.root, path: dir.path, op: opRemove, dir: true}, true
+	}
+
+	ev := event{
+		root:   dir.root,
+		path:   filepath.Join(dir.path, name),
+		cookie: raw.Cookie,
+		dir:    raw.Mask&syscall.IN_ISDIR != 0,
+	}
+	switch {
+	case raw.Mask&syscall.IN_MOVED_FROM != 0:
+		ev.op = opMovedFrom
+	case raw.Mask&syscall.IN_MOVED_TO != 0:
+		ev.op = opMovedTo
+	case raw.Mask&syscall.IN_DELETE != 0:
+		ev.op = opRemove
+	default:
+		ev.op = opWrite
+	}
+
+	if ev.dir && w.recursive && (ev.op == opWrite || ev.op == opMovedTo) {
+		// Files created before the watch is in place are found by the rescan.
+		_ = w.addTree(ev.path, dir.root)
+	}
+	return ev, true
+}
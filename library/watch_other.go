@@ -0,0 +1,12 @@
+//go:build !linux
+
+package library
+
+import (
+	"context"
+	"errors"
+)
+
+func watchDirs(ctx context.Context, roots []string, recursive bool, events chan<- event) error {
+	return errors.New("watching wallpaper directories is only supported on Linux")
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package library_test
+
+import (
+	"context"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/library"
+)
+
+func TestWatch(t *testing.T) {
+	home := testutil.Home(t)
+	dir := filepath.Join(home, "wallpapers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	testutil.WriteImage(t, filepath.Join(dir, "a.png"), 1, 1, color.Black)
+	options := library.Options{Dirs: []string{dir}}
+	if _, err := library.Scan(options, false); err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+
+	changes := make(chan library.Change, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- library.Watch(ctx, options, nil, func(change library.Change) error {
+			changes <- change
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch() failed: %v", err)
+		}
+	})
+
+	next := func() library.Change {
+		t.Helper()
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change reported")
+			return library.Change{}
+		}
+	}
+
+	// Give the watcher time to set up its watches.
+	time.Sleep(100 * time.Millisecond)
+
+	testutil.WriteImage(t, filepath.Join(dir, "b.png"), 1, 1, color.Black)
+	if change := next(); change.Result.Added != 1 {
+		t.Errorf("after create: %+v, want 1 added", change)
+	}
+
+	if err := os.Rename(filepath.Join(dir, "a.png"), filepath.Join(dir, "c.png")); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	change := next()
+	if got := change.Renamed[filepath.Join(dir, "a.png")]; got != filepath.Join(dir, "c.png") || len(change.Removed) != 0 {
+		t.Errorf("after rename: %+v, want a.png renamed to c.png", change)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "b.png")); err != nil {
+		t.Fatalf("Failed to remove: %v", err)
+	}
+	change = next()
+	if len(change.Removed) != 1 || change.Removed[0] != filepath.Join(dir, "b.png") {
+		t.Errorf("after remove: %+v, want b.png removed", change)
+	}
+}
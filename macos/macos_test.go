@@ -1,43 +1,37 @@
-package macos_test
+package macos
 
 import (
-	"os"
-	"path/filepath"
 	"testing"
-
-	"github.com/marcosalvi-01/wallman/macos"
 )
 
-func TestNew(t *testing.T) {
-	tempDir := t.TempDir()
-	wallpaperDir := filepath.Join(tempDir, "wallpapers")
-
-	err := os.MkdirAll(wallpaperDir, 0o750)
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-
-	// Create some test image files
-	files := []string{"test.png", "image.jpeg", "test.jpg", "image.bmp", "test.webp", "notimage.txt"}
-	for _, file := range files {
-		path := filepath.Join(wallpaperDir, file)
-		f, err := os.Create(path)
-		if err != nil {
-			t.Fatalf("Failed to create file %s: %v", file, err)
-		}
-		err = f.Close()
-		if err != nil {
-			t.Fatalf("Failed to close file %s: %v", file, err)
-		}
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "plain path",
+			path: "/walls/a.png",
+			want: `tell application "System Events" to set picture of every desktop to POSIX file "/walls/a.png"`,
+		},
+		{
+			name: "quotes and backslashes",
+			path: `/walls/"a"\b.png`,
+			want: `tell application "System Events" to set picture of every desktop to POSIX file "/walls/\"a\"\\b.png"`,
+		},
 	}
-
-	// Test New
-	m, err := macos.New([]string{wallpaperDir}, false, nil, true) // dryRun true, queries nil for simplicity
-	if err != nil {
-		t.Fatalf("New() failed: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := script(tt.path); got != tt.want {
+				t.Errorf("script() = %v, want %v", got, tt.want)
+			}
+		})
 	}
+}
 
-	if m == nil {
-		t.Fatal("New() returned nil")
+func TestApplyRejectsOutputs(t *testing.T) {
+	if err := New().Apply("/walls/a.png", "fill", []string{"DP-1"}); err == nil {
+		t.Error("Apply() succeeded on a single output")
 	}
 }
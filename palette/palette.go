@@ -0,0 +1,237 @@
+// Package palette extracts the dominant colors of a wallpaper with the median
+// cut algorithm and turns them into color themes rendered through text
+// templates. Palettes are cached in the database, so every wallpaper is only
+// analysed once.
+package palette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // decoder
+	_ "image/jpeg" // decoder
+	_ "image/png"  // decoder
+	"math"
+	"os"
+	"slices"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/db/sqlc"
+)
+
+// DefaultCount is the number of colors extracted when none is given.
+const DefaultCount = 8
+
+// maxSamples bounds the number of pixels looked at, large images are sampled
+// on a grid.
+const maxSamples = 1 << 16
+
+// ErrUnsupported is returned for wallpapers that cannot be decoded, such as
+// BMP and WEBP files: only JPEG, PNG and GIF images can be analysed.
+var ErrUnsupported = errors.New("only JPEG, PNG and GIF wallpapers can be analysed")
+
+// Color is an opaque color. It is written as "#rrggbb" in JSON.
+type Color struct {
+	R, G, B uint8
+}
+
+// Hex returns the color as "#rrggbb".
+func (c Color) Hex() string {
+	return "#" + c.Bare()
+}
+
+// Bare returns the color as "rrggbb", without the hash.
+func (c Color) Bare() string {
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.Hex()), nil
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	if err != nil {
+		return fmt.Errorf("invalid color %q: %w", text, err)
+	}
+	return nil
+}
+
+// luminance returns the relative luminance of the color, from 0 to 1.
+func (c Color) luminance() float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// Swatch is a dominant color and the share of the image it stands for.
+type Swatch struct {
+	Color  Color   `json:"color"`
+	Weight float64 `json:"weight"`
+}
+
+// Load returns up to count dominant colors of the wallpaper at path, most
+// common first, from the cache when the file did not change since it was
+// analysed.
+func Load(path string, count int) ([]Swatch, error) {
+	if count <= 0 {
+		count = DefaultCount
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access wallpaper file: %w", err)
+	}
+
+	cached, found, err := db.GetPalette(path)
+	if err != nil {
+		return nil, err
+	}
+	if found && cached.Size == info.Size() && cached.Mtime.Equal(info.ModTime()) && cached.Count == int64(count) {
+		var swatches []Swatch
+		if err := json.Unmarshal([]byte(cached.Colors), &swatches); err == nil {
+			return swatches, nil
+		}
+		// A broken cache entry is simply replaced.
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wallpaper: %w", err)
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnsupported, path, err)
+	}
+
+	swatches := Extract(img, count)
+	colors, err := json.Marshal(swatches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode palette: %w", err)
+	}
+	err = db.SetPalette(sqlc.Palette{
+		Path:   path,
+		Size:   info.Size(),
+		Mtime:  info.ModTime(),
+		Count:  int64(count),
+		Colors: string(colors),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return swatches, nil
+}
+
+// Extract returns up to count dominant colors of img, most common first.
+// Transparent pixels are ignored.
+func Extract(img image.Image, count int) []Swatch {
+	bounds := img.Bounds()
+	step := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/maxSamples)))
+
+	var pixels [][3]uint8
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			// Undo the alpha premultiplication.
+			pixels = append(pixels, [3]uint8{uint8(r * 0xff / a), uint8(g * 0xff / a), uint8(b * 0xff / a)})
+		}
+	}
+	if len(pixels) == 0 {
+		return nil
+	}
+
+	boxes := medianCut(pixels, count)
+	swatches := make([]Swatch, len(boxes))
+	for i, box := range boxes {
+		var sum [3]int
+		for _, p := range box {
+			sum[0] += int(p[0])
+			sum[1] += int(p[1])
+			sum[2] += int(p[2])
+		}
+		n := len(box)
+		swatches[i] = Swatch{
+			Color:  Color{R: uint8((sum[0] + n/2) / n), G: uint8((sum[1] + n/2) / n), B: uint8((sum[2] + n/2) / n)},
+			Weight: float64(n) / float64(len(pixels)),
+		}
+	}
+	slices.SortStableFunc(swatches, func(a, b Swatch) int {
+		switch {
+		case a.Weight > b.Weight:
+			return -1
+		case a.Weight < b.Weight:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return swatches
+}
+
+// medianCut splits pixels into up to count boxes, always splitting the box
+// with the most pixels times color range near the median of its widest
+// channel.
+func medianCut(pixels [][3]uint8, count int) [][][3]uint8 {
+	boxes := [][][3]uint8{pixels}
+	for len(boxes) < count {
+		best, bestChannel, bestScore := -1, 0, 0
+		for i, box := range boxes {
+			channel, span := widest(box)
+			if score := span * len(box); span > 0 && score > bestScore {
+				best, bestChannel, bestScore = i, channel, score
+			}
+		}
+		if best < 0 {
+			// Every box holds a single color.
+			break
+		}
+
+		box := boxes[best]
+		slices.SortFunc(box, func(a, b [3]uint8) int {
+			return int(a[bestChannel]) - int(b[bestChannel])
+		})
+		middle := split(box, bestChannel)
+		boxes[best] = box[:middle]
+		boxes = append(boxes, box[middle:])
+	}
+	return boxes
+}
+
+// split returns the index closest to the median of box, sorted on channel,
+// where the channel changes, so that a color never ends up in both halves.
+func split(box [][3]uint8, channel int) int {
+	middle := len(box) / 2
+	for offset := range len(box) {
+		for _, i := range []int{middle + offset, middle - offset} {
+			if i > 0 && i < len(box) && box[i-1][channel] != box[i][channel] {
+				return i
+			}
+		}
+	}
+	return middle
+}
+
+// widest returns the channel of box with the largest range and that range.
+func widest(box [][3]uint8) (channel, span int) {
+	for c := range 3 {
+		low, high := 255, 0
+		for _, p := range box {
+			low = min(low, int(p[c]))
+			high = max(high, int(p[c]))
+		}
+		if high-low > span {
+			channel, span = c, high-low
+		}
+	}
+	return channel, span
+}
@@ -0,0 +1,143 @@
+package palette_test
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/palette"
+)
+
+// twoColors returns an image whose left quarter is left and the rest right.
+func twoColors(left, right color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	for y := range 10 {
+		for x := range 40 {
+			if x < 10 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func TestExtract(t *testing.T) {
+	red := color.RGBA{R: 200, G: 20, B: 20, A: 255}
+	blue := color.RGBA{R: 10, G: 30, B: 180, A: 255}
+
+	swatches := palette.Extract(twoColors(red, blue), 4)
+	if len(swatches) != 2 {
+		t.Fatalf("Expected 2 colors, got %v", swatches)
+	}
+	if swatches[0].Color.Hex() != "#0a1eb4" || swatches[1].Color.Hex() != "#c81414" {
+		t.Errorf("Expected blue then red, got %s and %s", swatches[0].Color.Hex(), swatches[1].Color.Hex())
+	}
+	if swatches[0].Weight != 0.75 || swatches[1].Weight != 0.25 {
+		t.Errorf("Expected weights 0.75 and 0.25, got %v and %v", swatches[0].Weight, swatches[1].Weight)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testutil.Home(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wall.png")
+	testutil.WritePNG(t, path, twoColors(color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}))
+
+	swatches, err := palette.Load(path, 3)
+	if err != nil {
+		t.Fatalf("Failed to load palette: %v", err)
+	}
+	if len(swatches) != 2 || swatches[0].Color.Hex() != "#00ff00" {
+		t.Fatalf("Unexpected palette %v", swatches)
+	}
+
+	cached, found, err := db.GetPalette(path)
+	if err != nil || !found {
+		t.Fatalf("Expected a cached palette, got %v, %v", found, err)
+	}
+	if cached.Count != 3 || !strings.Contains(cached.Colors, "#00ff00") {
+		t.Errorf("Unexpected cached palette %+v", cached)
+	}
+
+	// A changed file is analysed again.
+	testutil.WritePNG(t, path, twoColors(color.RGBA{B: 255, A: 255}, color.RGBA{B: 255, A: 255}))
+	fresh, err := palette.Load(path, 3)
+	if err != nil {
+		t.Fatalf("Failed to load palette: %v", err)
+	}
+	if len(fresh) != 1 || fresh[0].Color.Hex() != "#0000ff" {
+		t.Errorf("Expected the new palette, got %v", fresh)
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	testutil.Home(t)
+	path := filepath.Join(t.TempDir(), "wall.webp")
+	if err := os.WriteFile(path, []byte("RIFF\x00\x00\x00\x00WEBP"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := palette.Load(path, 0)
+	if !errors.Is(err, palette.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	swatches := palette.Extract(twoColors(color.RGBA{R: 30, G: 30, B: 60, A: 255}, color.RGBA{R: 220, G: 200, B: 120, A: 255}), 8)
+	theme := palette.NewTheme("/walls/it's.png", swatches)
+	if len(theme.Terminal) != 16 {
+		t.Fatalf("Expected 16 terminal colors, got %d", len(theme.Terminal))
+	}
+
+	tests := []struct {
+		format string
+		custom map[string]string
+		want   []string
+	}{
+		{"env", nil, []string{`WALLMAN_WALLPAPER='/walls/it'\''s.png'`, "WALLMAN_COLOR15='" + theme.Foreground.Hex() + "'"}},
+		{"css", nil, []string{"--wallman-background: " + theme.Background.Hex() + ";", "--wallman-color0:"}},
+		{"kitty", nil, []string{"background " + theme.Background.Hex(), "color15 "}},
+		{"alacritty", nil, []string{"[colors.bright]", `magenta = "` + theme.Terminal[13].Hex() + `"`}},
+		{"foot", nil, []string{"background=" + theme.Background.Bare(), "bright7="}},
+		{"hypr", map[string]string{"hypr": "$bg = rgb({{ .Background.Bare }})"}, []string{"$bg = rgb(" + theme.Background.Bare() + ")"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := palette.Render(tt.format, tt.custom, theme)
+			if err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("Expected %q in:\n%s", want, out)
+				}
+			}
+		})
+	}
+
+	out, err := palette.Render("json", nil, theme)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	var decoded palette.Theme
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Invalid JSON palette: %v", err)
+	}
+	if decoded.Background != theme.Background || len(decoded.Colors) != 2 {
+		t.Errorf("Unexpected JSON palette %+v", decoded)
+	}
+
+	if _, err := palette.Render("xresources", nil, theme); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
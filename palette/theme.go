@@ -0,0 +1,219 @@
+package palette
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Theme is what the templates are rendered with: the dominant colors of a
+// wallpaper and a terminal color scheme derived from them.
+type Theme struct {
+	Wallpaper string `json:"wallpaper"`
+	// Colors are the dominant colors, most common first.
+	Colors     []Swatch `json:"colors"`
+	Background Color    `json:"background"`
+	Foreground Color    `json:"foreground"`
+	// Terminal holds the 16 ANSI colors, color0 to color15.
+	Terminal []Color `json:"terminal"`
+}
+
+var (
+	black = Color{}
+	white = Color{R: 255, G: 255, B: 255}
+)
+
+// NewTheme derives a theme from the dominant colors of wallpaper: the darkest
+// color makes the background, the lightest the foreground and the others,
+// ordered by hue, the accents.
+func NewTheme(wallpaper string, swatches []Swatch) Theme {
+	theme := Theme{Wallpaper: wallpaper, Colors: swatches}
+	if len(swatches) == 0 {
+		swatches = []Swatch{{Color: black}, {Color: white}}
+	}
+
+	colors := make([]Color, len(swatches))
+	for i, swatch := range swatches {
+		colors[i] = swatch.Color
+	}
+	slices.SortStableFunc(colors, func(a, b Color) int {
+		return compareFloats(a.luminance(), b.luminance())
+	})
+	theme.Background = mix(colors[0], black, 0.5)
+	theme.Foreground = mix(colors[len(colors)-1], white, 0.6)
+
+	accents := colors
+	if len(colors) > 2 {
+		accents = colors[1 : len(colors)-1]
+	}
+	accents = slices.Clone(accents)
+	slices.SortStableFunc(accents, func(a, b Color) int {
+		return compareFloats(hue(a), hue(b))
+	})
+
+	terminal := make([]Color, 16)
+	terminal[0] = theme.Background
+	terminal[7] = mix(theme.Foreground, theme.Background, 0.2)
+	terminal[8] = mix(theme.Background, theme.Foreground, 0.3)
+	terminal[15] = theme.Foreground
+	for i := range 6 {
+		accent := readable(accents[i%len(accents)])
+		terminal[1+i] = accent
+		terminal[9+i] = mix(accent, white, 0.3)
+	}
+	theme.Terminal = terminal
+	return theme
+}
+
+// mix blends a with b, t being the share of b.
+func mix(a, b Color, t float64) Color {
+	blend := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-t) + float64(y)*t + 0.5)
+	}
+	return Color{R: blend(a.R, b.R), G: blend(a.G, b.G), B: blend(a.B, b.B)}
+}
+
+// readable lightens c until it stands out on a dark background.
+func readable(c Color) Color {
+	for range 10 {
+		if c.luminance() >= 0.2 {
+			break
+		}
+		c = mix(c, white, 0.15)
+	}
+	return c
+}
+
+// hue returns the hue of c in degrees.
+func hue(c Color) float64 {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	high, low := max(r, g, b), min(r, g, b)
+	if high == low {
+		return 0
+	}
+	var h float64
+	switch high {
+	case r:
+		h = (g - b) / (high - low)
+	case g:
+		h = 2 + (b-r)/(high-low)
+	default:
+		h = 4 + (r-g)/(high-low)
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Built-in formats, each a template that can be overridden.
+var formats = map[string]string{
+	"json": `{{ json . }}
+`,
+	"env": `WALLMAN_WALLPAPER={{ quote .Wallpaper }}
+WALLMAN_BACKGROUND='{{ .Background.Hex }}'
+WALLMAN_FOREGROUND='{{ .Foreground.Hex }}'
+{{ range $i, $c := .Terminal }}WALLMAN_COLOR{{ $i }}='{{ $c.Hex }}'
+{{ end }}`,
+	"css": `:root {
+  --wallman-background: {{ .Background.Hex }};
+  --wallman-foreground: {{ .Foreground.Hex }};
+{{ range $i, $c := .Terminal }}  --wallman-color{{ $i }}: {{ $c.Hex }};
+{{ end }}}
+`,
+	"kitty": `background {{ .Background.Hex }}
+foreground {{ .Foreground.Hex }}
+cursor {{ .Foreground.Hex }}
+selection_background {{ (index .Terminal 8).Hex }}
+selection_foreground {{ .Foreground.Hex }}
+{{ range $i, $c := .Terminal }}color{{ $i }} {{ $c.Hex }}
+{{ end }}`,
+	"alacritty": `[colors.primary]
+background = "{{ .Background.Hex }}"
+foreground = "{{ .Foreground.Hex }}"
+
+[colors.normal]
+{{ range $i, $name := names }}{{ $name }} = "{{ (index $.Terminal $i).Hex }}"
+{{ end }}
+[colors.bright]
+{{ range $i, $name := names }}{{ $name }} = "{{ (index $.Terminal (add $i 8)).Hex }}"
+{{ end }}`,
+	"foot": `[colors]
+background={{ .Background.Bare }}
+foreground={{ .Foreground.Bare }}
+{{ range $i, $c := slice .Terminal 0 8 }}regular{{ $i }}={{ $c.Bare }}
+{{ end }}{{ range $i, $c := slice .Terminal 8 16 }}bright{{ $i }}={{ $c.Bare }}
+{{ end }}`,
+}
+
+// Formats returns the names of the built-in formats and of the custom ones.
+func Formats(custom map[string]string) []string {
+	names := slices.Collect(maps.Keys(formats))
+	for name := range custom {
+		if _, ok := formats[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	},
+	"quote": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+	"mix": mix,
+	"add": func(a, b int) int { return a + b },
+	"names": func() []string {
+		return []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+	},
+}
+
+// Parse parses the template of format, one of custom or a built-in one.
+func Parse(format string, custom map[string]string) (*template.Template, error) {
+	text, ok := custom[format]
+	if !ok {
+		text, ok = formats[format]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown palette format %q (expected one of %s)", format, strings.Join(Formats(custom), ", "))
+	}
+
+	tmpl, err := template.New(format).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid palette format %s: %w", format, err)
+	}
+	return tmpl, nil
+}
+
+// Render renders theme in format, one of custom or a built-in one.
+func Render(format string, custom map[string]string, theme Theme) (string, error) {
+	tmpl, err := Parse(format, custom)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, theme); err != nil {
+		return "", fmt.Errorf("failed to render palette format %s: %w", format, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,331 @@
+// Package pipeline derives the image handed to the backend from a wallpaper:
+// it can scale and crop it to the resolution of the monitor, blur it, dim it
+// and tint it. Derived images are cached under ~/.local/share/wallman/cache,
+// keyed by the content of the wallpaper and the settings that produced them,
+// so every combination is only computed once. The hash of the content is
+// cached in the database while the size and modification time of the file do
+// not change. The least recently used images are dropped once the cache outgrows
+// MaxCacheSize.
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decoder
+	"image/jpeg"
+	_ "image/png" // decoder
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/db"
+	"github.com/marcosalvi-01/wallman/db/sqlc"
+)
+
+// defaultTintStrength is used when a tint has no strength.
+const defaultTintStrength = 0.25
+
+// MaxCacheSize is how many bytes of derived images are kept.
+const MaxCacheSize = 512 << 20
+
+// ErrUnsupported is returned for wallpapers that cannot be decoded, such as
+// BMP and WEBP files: only JPEG, PNG and GIF images can be processed.
+var ErrUnsupported = errors.New("only JPEG, PNG and GIF wallpapers can be processed")
+
+// Pipeline lists the steps applied to a wallpaper, in this order.
+type Pipeline struct {
+	// Resize scales and crops the image to the resolution of the monitor.
+	Resize bool `yaml:"resize,omitempty"`
+	// Blur is the standard deviation of a gaussian blur, in pixels.
+	Blur float64 `yaml:"blur,omitempty"`
+	// Dim darkens the image, from 0 (unchanged) to 1 (black).
+	Dim float64 `yaml:"dim,omitempty"`
+	// Tint is a "#rrggbb" color blended over the image.
+	Tint string `yaml:"tint,omitempty"`
+	// TintStrength is how much of the tint is blended in, from 0 to 1. Zero
+	// means 0.25.
+	TintStrength float64 `yaml:"tint_strength,omitempty"`
+}
+
+// Validate reports invalid settings.
+func (p Pipeline) Validate() error {
+	if p.Blur < 0 {
+		return fmt.Errorf("invalid blur %g (must not be negative)", p.Blur)
+	}
+	if p.Dim < 0 || p.Dim > 1 {
+		return fmt.Errorf("invalid dim %g (expected a value between 0 and 1)", p.Dim)
+	}
+	if p.TintStrength < 0 || p.TintStrength > 1 {
+		return fmt.Errorf("invalid tint_strength %g (expected a value between 0 and 1)", p.TintStrength)
+	}
+	if p.Tint != "" {
+		if _, err := parseColor(p.Tint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether the pipeline leaves wallpapers unchanged.
+func (p Pipeline) IsZero() bool {
+	return !p.Resize && p.Blur == 0 && p.Dim == 0 && p.Tint == ""
+}
+
+// Process returns the path of the image derived from the wallpaper at path,
+// computing it unless it is cached. width and height are the resolution of
+// the monitor, used by Resize; when they are zero the image is not resized.
+func Process(path string, p Pipeline, width, height int) (string, error) {
+	if !p.Resize {
+		width, height = 0, 0
+	}
+
+	sum, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	source := &source{path: path}
+	return cached(cacheKey(sum, p, fmt.Sprintf("%dx%d", width, height)), func() (image.Image, error) {
+		r, err := source.raster()
+		if err != nil {
+			return nil, err
+		}
+		if width > 0 && height > 0 {
+			r = r.cover(width, height)
+		}
+		return p.apply(r)
+	})
+}
+
+// source decodes a wallpaper the first time it is needed.
+type source struct {
+	path    string
+	decoded *raster
+}
+
+func (s *source) raster() (*raster, error) {
+	if s.decoded != nil {
+		return s.decoded, nil
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wallpaper: %w", err)
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnsupported, s.path, err)
+	}
+	s.decoded = fromImage(img)
+	return s.decoded, nil
+}
+
+// cached returns the path of the cached image named key, calling render to
+// create it if it does not exist yet.
+func cached(key string, render func() (image.Image, error)) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, key+".jpg")
+	if _, err := os.Stat(path); err == nil {
+		// The modification time tells prune which images are in use.
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		return path, nil
+	}
+
+	img, err := render()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return "", fmt.Errorf("error creating cache directory %s: %w", dir, err)
+	}
+	// Write to a temporary file first, so that a concurrent run never picks
+	// up a half written image.
+	tmp, err := os.CreateTemp(dir, key+"-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cached image: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = jpeg.Encode(tmp, img, &jpeg.Options{Quality: 95})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write cached image: %w", err)
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", fmt.Errorf("failed to write cached image: %w", err)
+	}
+
+	if _, _, err := prune(dir, MaxCacheSize, path); err != nil {
+		log.Printf("failed to prune the image cache: %v", err)
+	}
+	return path, nil
+}
+
+// CleanCache deletes every derived image, returning how many were deleted
+// and the bytes they took.
+func CleanCache() (removed int, freed int64, err error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return 0, 0, err
+	}
+	return prune(dir, 0, "")
+}
+
+// prune deletes the least recently used images of dir until they take at
+// most size bytes. The image at keep, just written, stays.
+func prune(dir string, size int64, keep string) (removed int, freed int64, err error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var images []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".jpg" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Deleted meanwhile.
+			continue
+		}
+		images = append(images, info)
+		total += info.Size()
+	}
+	slices.SortFunc(images, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+
+	for _, info := range images {
+		if total <= size {
+			break
+		}
+		path := filepath.Join(dir, info.Name())
+		if path == keep {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, freed, fmt.Errorf("failed to remove cached image: %w", err)
+		}
+		removed++
+		freed += info.Size()
+		total -= info.Size()
+	}
+	return removed, freed, nil
+}
+
+// CacheDir returns the directory of the derived images.
+func CacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home dir: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", "wallman", "cache"), nil
+}
+
+// apply runs the steps after Resize on r.
+func (p Pipeline) apply(r *raster) (image.Image, error) {
+	if p.Blur > 0 {
+		r.blur(p.Blur)
+	}
+	if p.Dim > 0 {
+		r.dim(p.Dim)
+	}
+	if p.Tint != "" {
+		color, err := parseColor(p.Tint)
+		if err != nil {
+			return nil, err
+		}
+		strength := p.TintStrength
+		if strength == 0 {
+			strength = defaultTintStrength
+		}
+		r.tint(color, strength)
+	}
+	return r.image(), nil
+}
+
+// hashFile hashes the content of the wallpaper at path, so that editing it
+// invalidates the cache. The hash is only computed again when the size or
+// the modification time of the file changed.
+func hashFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access wallpaper: %w", err)
+	}
+	stored, found, err := db.GetFileHash(path)
+	if err != nil {
+		return nil, err
+	}
+	if found && stored.Size == info.Size() && stored.Mtime.Equal(info.ModTime()) {
+		if sum, err := hex.DecodeString(stored.Hash); err == nil {
+			return sum, nil
+		}
+		// A broken cache entry is simply replaced.
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wallpaper: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to read wallpaper: %w", err)
+	}
+	sum := hash.Sum(nil)
+	err = db.SetFileHash(sqlc.FileHash{
+		Path:  path,
+		Size:  info.Size(),
+		Mtime: info.ModTime(),
+		Hash:  hex.EncodeToString(sum),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// cacheKey combines the hash of a wallpaper with the settings making an
+// image out of it. geometry describes the area and resolution of the image.
+func cacheKey(sum []byte, p Pipeline, geometry string) string {
+	hash := sha256.New()
+	hash.Write(sum)
+	fmt.Fprintf(hash, "\x00%s blur=%g dim=%g tint=%s/%g",
+		geometry, p.Blur, p.Dim, strings.ToLower(p.Tint), p.TintStrength)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// parseColor parses a "#rrggbb" color.
+func parseColor(s string) ([3]float32, error) {
+	digits := strings.TrimPrefix(s, "#")
+	if len(digits) != 6 {
+		return [3]float32{}, fmt.Errorf("invalid tint %q (expected #rrggbb)", s)
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return [3]float32{}, fmt.Errorf("invalid tint %q (expected #rrggbb)", s)
+	}
+	return [3]float32{float32(value >> 16 & 0xff), float32(value >> 8 & 0xff), float32(value & 0xff)}, nil
+}
@@ -0,0 +1,222 @@
+package pipeline_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+// writeImage writes a PNG of the given size filled with c.
+func readImage(t *testing.T, path string) image.Image {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	img, err := jpeg.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return img
+}
+
+// near reports whether the color at x, y of img is within 3 of want.
+func near(img image.Image, x, y int, want color.RGBA) bool {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return math.Abs(float64(r>>8)-float64(want.R)) <= 3 &&
+		math.Abs(float64(g>>8)-float64(want.G)) <= 3 &&
+		math.Abs(float64(b>>8)-float64(want.B)) <= 3
+}
+
+func TestProcess(t *testing.T) {
+	home := testutil.Home(t)
+	source := filepath.Join(home, "wall.png")
+	gray := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	testutil.WriteImage(t, source, 64, 32, gray)
+
+	tests := []struct {
+		name       string
+		pipeline   pipeline.Pipeline
+		width      int
+		height     int
+		wantWidth  int
+		wantHeight int
+		want       color.RGBA
+	}{
+		{"resize", pipeline.Pipeline{Resize: true}, 20, 40, 20, 40, gray},
+		{"no monitor size", pipeline.Pipeline{Resize: true}, 0, 0, 64, 32, gray},
+		{"blur keeps flat colors", pipeline.Pipeline{Blur: 5}, 0, 0, 64, 32, gray},
+		{"dim", pipeline.Pipeline{Dim: 0.5}, 0, 0, 64, 32, color.RGBA{R: 100, G: 100, B: 100}},
+		{"tint", pipeline.Pipeline{Tint: "#ff0000", TintStrength: 0.5}, 0, 0, 64, 32, color.RGBA{R: 228, G: 100, B: 100}},
+		{"everything", pipeline.Pipeline{Resize: true, Blur: 2, Dim: 0.5, Tint: "#000000", TintStrength: 0.5}, 16, 16, 16, 16, color.RGBA{R: 50, G: 50, B: 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := pipeline.Process(source, tt.pipeline, tt.width, tt.height)
+			if err != nil {
+				t.Fatalf("Process() failed: %v", err)
+			}
+			img := readImage(t, path)
+			if got := img.Bounds().Size(); got != image.Pt(tt.wantWidth, tt.wantHeight) {
+				t.Errorf("Process() made a %v image, want %dx%d", got, tt.wantWidth, tt.wantHeight)
+			}
+			for _, p := range []image.Point{{0, 0}, {tt.wantWidth / 2, tt.wantHeight / 2}, {tt.wantWidth - 1, tt.wantHeight - 1}} {
+				if !near(img, p.X, p.Y, tt.want) {
+					t.Errorf("pixel %v = %v, want %v", p, img.At(p.X, p.Y), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessCache(t *testing.T) {
+	home := testutil.Home(t)
+	source := filepath.Join(home, "wall.png")
+	testutil.WriteImage(t, source, 8, 8, color.White)
+	dim := pipeline.Pipeline{Dim: 0.5}
+
+	first, err := pipeline.Process(source, dim, 0, 0)
+	if err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+	dir, err := pipeline.CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() failed: %v", err)
+	}
+	if filepath.Dir(first) != dir {
+		t.Errorf("Process() = %s, want a file in %s", first, dir)
+	}
+
+	again, err := pipeline.Process(source, dim, 0, 0)
+	if err != nil || again != first {
+		t.Errorf("second Process() = %s, %v, want the cached %s", again, err, first)
+	}
+
+	other, err := pipeline.Process(source, pipeline.Pipeline{Dim: 0.25}, 0, 0)
+	if err != nil || other == first {
+		t.Errorf("Process() with other settings = %s, %v, want a new image", other, err)
+	}
+
+	// Copies and touched files have the same content.
+	data, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(home, "copy.png")
+	if err := os.WriteFile(copied, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(source, later, later); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{copied, source} {
+		shared, err := pipeline.Process(path, dim, 0, 0)
+		if err != nil || shared != first {
+			t.Errorf("Process(%s) = %s, %v, want the cached %s", filepath.Base(path), shared, err, first)
+		}
+	}
+
+	testutil.WriteImage(t, source, 8, 8, color.Black)
+	changed, err := pipeline.Process(source, dim, 0, 0)
+	if err != nil || changed == first {
+		t.Errorf("Process() of an edited wallpaper = %s, %v, want a new image", changed, err)
+	}
+}
+
+func TestProcessUnsupported(t *testing.T) {
+	home := testutil.Home(t)
+	source := filepath.Join(home, "wall.webp")
+	if err := os.WriteFile(source, []byte("RIFF\x00\x00\x00\x00WEBPVP8L"), 0o600); err != nil {
+		t.Fatalf("Failed to create %s: %v", source, err)
+	}
+
+	_, err := pipeline.Process(source, pipeline.Pipeline{Dim: 0.5}, 0, 0)
+	if !errors.Is(err, pipeline.ErrUnsupported) {
+		t.Errorf("Process() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline pipeline.Pipeline
+		wantErr  bool
+	}{
+		{"empty", pipeline.Pipeline{}, false},
+		{"valid", pipeline.Pipeline{Resize: true, Blur: 3, Dim: 0.4, Tint: "#A0b0c0", TintStrength: 0.1}, false},
+		{"negative blur", pipeline.Pipeline{Blur: -1}, true},
+		{"dim above one", pipeline.Pipeline{Dim: 1.5}, true},
+		{"short tint", pipeline.Pipeline{Tint: "#fff"}, true},
+		{"tint strength above one", pipeline.Pipeline{Tint: "#ffffff", TintStrength: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pipeline.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessCropsAndBlurs(t *testing.T) {
+	home := testutil.Home(t)
+	source := filepath.Join(home, "wall.png")
+
+	// Black, then white for the middle half, then black again.
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := range 32 {
+		for x := range 64 {
+			if x >= 16 && x < 48 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", source, err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode %s: %v", source, err)
+	}
+	file.Close()
+
+	// A square crop only keeps the white middle, the filter only blends the
+	// outermost pixels with their neighbours.
+	path, err := pipeline.Process(source, pipeline.Pipeline{Resize: true}, 16, 16)
+	if err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+	white := color.RGBA{R: 255, G: 255, B: 255}
+	if cropped := readImage(t, path); !near(cropped, 1, 8, white) || !near(cropped, 14, 8, white) {
+		t.Errorf("cropped image is not white: %v, %v", cropped.At(1, 8), cropped.At(14, 8))
+	}
+
+	// Blurring mixes the colors around the edges but not far from them.
+	path, err = pipeline.Process(source, pipeline.Pipeline{Blur: 2}, 0, 0)
+	if err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+	blurred := readImage(t, path)
+	if near(blurred, 16, 16, white) || near(blurred, 15, 16, color.RGBA{}) {
+		t.Errorf("edge is not blurred: %v, %v", blurred.At(15, 16), blurred.At(16, 16))
+	}
+	if !near(blurred, 32, 16, white) || !near(blurred, 0, 16, color.RGBA{}) {
+		t.Errorf("blur spread too far: %v, %v", blurred.At(0, 16), blurred.At(32, 16))
+	}
+}
@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	// d.jpg was used last, b.jpg first.
+	for i, name := range []string{"b.jpg", "c.jpg", "a.jpg", "d.jpg"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		used := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), make([]byte, 1000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, freed, err := prune(dir, 250, filepath.Join(dir, "b.jpg"))
+	if err != nil {
+		t.Fatalf("prune() failed: %v", err)
+	}
+	if removed != 2 || freed != 200 {
+		t.Errorf("prune() = %d, %d, want 2 images of 200 bytes", removed, freed)
+	}
+	var left []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if want := []string{"b.jpg", "d.jpg", "other.txt"}; !slices.Equal(left, want) {
+		t.Errorf("prune() left %v, want %v", left, want)
+	}
+
+	if _, _, err := prune(filepath.Join(dir, "missing"), 0, ""); err != nil {
+		t.Errorf("prune() of a missing cache failed: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package pipeline
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// raster is an opaque RGB image with float channels, which keeps the
+// intermediate results of the steps from losing precision.
+type raster struct {
+	width  int
+	height int
+	// pix holds the red, green and blue values of every pixel, row by row,
+	// in the 0-255 range.
+	pix []float32
+}
+
+func newRaster(width, height int) *raster {
+	return &raster{width: width, height: height, pix: make([]float32, width*height*3)}
+}
+
+// fromImage converts img, compositing transparent parts over black.
+func fromImage(img image.Image) *raster {
+	bounds := img.Bounds()
+	// draw has fast paths from the decoded types to RGBA.
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	r := newRaster(bounds.Dx(), bounds.Dy())
+	for i := range r.width * r.height {
+		r.pix[i*3] = float32(rgba.Pix[i*4])
+		r.pix[i*3+1] = float32(rgba.Pix[i*4+1])
+		r.pix[i*3+2] = float32(rgba.Pix[i*4+2])
+	}
+	return r
+}
+
+func (r *raster) image() *image.RGBA {
+	rgba := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	for i := range r.width * r.height {
+		rgba.Pix[i*4] = clamp(r.pix[i*3])
+		rgba.Pix[i*4+1] = clamp(r.pix[i*3+1])
+		rgba.Pix[i*4+2] = clamp(r.pix[i*3+2])
+		rgba.Pix[i*4+3] = 255
+	}
+	return rgba
+}
+
+func clamp(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
+
+// cover scales the image to cover width x height and crops the overflow
+// evenly on both sides.
+func (r *raster) cover(width, height int) *raster {
+	scale := math.Max(float64(width)/float64(r.width), float64(height)/float64(r.height))
+	cropWidth := float64(width) / scale
+	cropHeight := float64(height) / scale
+	x := (float64(r.width) - cropWidth) / 2
+	y := (float64(r.height) - cropHeight) / 2
+	return r.resample(x, y, cropWidth, cropHeight, width, height)
+}
+
+// resample scales the area of the image at x, y of cropWidth x cropHeight
+// pixels to width x height pixels.
+func (r *raster) resample(x, y, cropWidth, cropHeight float64, width, height int) *raster {
+	columns := taps(x, cropWidth, r.width, width)
+	rows := taps(y, cropHeight, r.height, height)
+
+	// Resample the rows first, then the columns.
+	wide := newRaster(width, r.height)
+	for row := range r.height {
+		src := r.pix[row*r.width*3:]
+		dst := wide.pix[row*width*3:]
+		for col, weights := range columns {
+			var red, green, blue float32
+			for _, t := range weights {
+				red += src[t.index*3] * t.weight
+				green += src[t.index*3+1] * t.weight
+				blue += src[t.index*3+2] * t.weight
+			}
+			dst[col*3], dst[col*3+1], dst[col*3+2] = red, green, blue
+		}
+	}
+
+	out := newRaster(width, height)
+	for row, weights := range rows {
+		dst := out.pix[row*width*3 : (row+1)*width*3]
+		for _, t := range weights {
+			src := wide.pix[t.index*width*3 : (t.index+1)*width*3]
+			for i := range dst {
+				dst[i] += src[i] * t.weight
+			}
+		}
+	}
+	return out
+}
+
+type tap struct {
+	index  int
+	weight float32
+}
+
+// taps returns, for every one of the size output pixels, the weights of the
+// source pixels of the span [start, start+length) of an axis of srcSize
+// pixels, using a triangle filter widened when shrinking.
+func taps(start, length float64, srcSize, size int) [][]tap {
+	scale := length / float64(size)
+	support := math.Max(scale, 1)
+
+	all := make([][]tap, size)
+	for i := range all {
+		center := start + (float64(i)+0.5)*scale - 0.5
+		first := int(math.Floor(center - support))
+		last := int(math.Ceil(center + support))
+
+		var weights []tap
+		var total float64
+		for j := first; j <= last; j++ {
+			weight := 1 - math.Abs(float64(j)-center)/support
+			if weight <= 0 {
+				continue
+			}
+			weights = append(weights, tap{index: min(max(j, 0), srcSize-1), weight: float32(weight)})
+			total += weight
+		}
+		for k := range weights {
+			weights[k].weight /= float32(total)
+		}
+		all[i] = weights
+	}
+	return all
+}
+
+// blur applies a gaussian blur of standard deviation sigma, approximated by
+// three box blurs so that its cost does not depend on sigma.
+func (r *raster) blur(sigma float64) {
+	tmp := make([]float32, len(r.pix))
+	for _, box := range boxSizes(sigma, 3) {
+		radius := (box - 1) / 2
+		boxBlur(r.pix, tmp, r.width, r.height, radius)
+		boxBlur(tmp, r.pix, r.height, r.width, radius)
+	}
+}
+
+// boxSizes returns the widths of n box blurs adding up to a gaussian blur of
+// standard deviation sigma.
+func boxSizes(sigma float64, n int) []int {
+	ideal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	lower := int(math.Floor(ideal))
+	if lower%2 == 0 {
+		lower--
+	}
+	upper := lower + 2
+
+	idealLower := (12*sigma*sigma - float64(n*lower*lower) - 4*float64(n*lower) - 3*float64(n)) / (-4*float64(lower) - 4)
+	m := int(math.Round(idealLower))
+
+	sizes := make([]int, n)
+	for i := range sizes {
+		if i < m {
+			sizes[i] = lower
+		} else {
+			sizes[i] = upper
+		}
+	}
+	return sizes
+}
+
+// boxBlur averages every pixel of the lines rows of length pixels of src
+// with the radius pixels on each side, and writes the result transposed into
+// dst so that a second call blurs the columns and transposes it back.
+func boxBlur(src, dst []float32, length, lines, radius int) {
+	scale := 1 / float32(2*radius+1)
+	for line := range lines {
+		base := line * length * 3
+		for channel := range 3 {
+			at := func(i int) float32 {
+				return src[base+min(max(i, 0), length-1)*3+channel]
+			}
+
+			var sum float32
+			for i := -radius; i <= radius; i++ {
+				sum += at(i)
+			}
+			for i := range length {
+				// Transposed: line i of the output, pixel line.
+				dst[(i*lines+line)*3+channel] = sum * scale
+				sum += at(i+radius+1) - at(i-radius)
+			}
+		}
+	}
+}
+
+// dim darkens the image by amount, 1 being black.
+func (r *raster) dim(amount float64) {
+	factor := float32(1 - amount)
+	for i := range r.pix {
+		r.pix[i] *= factor
+	}
+}
+
+// tint blends the image with color by strength.
+func (r *raster) tint(color [3]float32, strength float64) {
+	s := float32(strength)
+	for i := range r.pix {
+		r.pix[i] = r.pix[i]*(1-s) + color[i%3]*s
+	}
+}
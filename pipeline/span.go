@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Output is a monitor an image is spanned across.
+type Output struct {
+	Name string
+	// X, Y, Width and Height are the area the output covers in the layout,
+	// in logical pixels.
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	// PixelWidth and PixelHeight are the resolution of the output.
+	PixelWidth  int
+	PixelHeight int
+}
+
+// Span slices the wallpaper at path across outputs as they are laid out,
+// scaling it to cover the whole layout, and runs every slice through p. It
+// returns the path of the cached slice of every output. Resize is implied.
+func Span(path string, p Pipeline, outputs []Output) (map[string]string, error) {
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no monitors to span the wallpaper across")
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, output := range outputs {
+		if output.Width <= 0 || output.Height <= 0 || output.PixelWidth <= 0 || output.PixelHeight <= 0 {
+			return nil, fmt.Errorf("invalid size of monitor %s", output.Name)
+		}
+		minX, minY = math.Min(minX, output.X), math.Min(minY, output.Y)
+		maxX, maxY = math.Max(maxX, output.X+output.Width), math.Max(maxY, output.Y+output.Height)
+	}
+	layoutWidth, layoutHeight := maxX-minX, maxY-minY
+
+	sum, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src := &source{path: path}
+	paths := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		geometry := fmt.Sprintf("span %gx%g %g,%g %gx%g %dx%d", layoutWidth, layoutHeight,
+			output.X-minX, output.Y-minY, output.Width, output.Height, output.PixelWidth, output.PixelHeight)
+		slice, err := cached(cacheKey(sum, p, geometry), func() (image.Image, error) {
+			r, err := src.raster()
+			if err != nil {
+				return nil, err
+			}
+
+			// Source pixels per layout pixel, so that the image covers the
+			// layout, centered like with Resize.
+			scale := math.Min(float64(r.width)/layoutWidth, float64(r.height)/layoutHeight)
+			offsetX := (float64(r.width) - layoutWidth*scale) / 2
+			offsetY := (float64(r.height) - layoutHeight*scale) / 2
+			sliced := r.resample(
+				offsetX+(output.X-minX)*scale, offsetY+(output.Y-minY)*scale,
+				output.Width*scale, output.Height*scale,
+				output.PixelWidth, output.PixelHeight,
+			)
+			return p.apply(sliced)
+		})
+		if err != nil {
+			return nil, err
+		}
+		paths[output.Name] = slice
+	}
+	return paths, nil
+}
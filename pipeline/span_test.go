@@ -0,0 +1,68 @@
+package pipeline_test
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcosalvi-01/wallman/internal/testutil"
+	"github.com/marcosalvi-01/wallman/pipeline"
+)
+
+func TestSpan(t *testing.T) {
+	home := testutil.Home(t)
+	source := filepath.Join(home, "wide.png")
+
+	// Red, green and blue thirds.
+	stripes := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}}
+	img := image.NewRGBA(image.Rect(0, 0, 90, 20))
+	for y := range 20 {
+		for x := range 90 {
+			img.Set(x, y, stripes[x/30])
+		}
+	}
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", source, err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode %s: %v", source, err)
+	}
+	file.Close()
+
+	// The middle monitor has twice the resolution on the same area.
+	outputs := []pipeline.Output{
+		{Name: "left", X: 0, Y: 0, Width: 30, Height: 20, PixelWidth: 30, PixelHeight: 20},
+		{Name: "middle", X: 30, Y: 0, Width: 30, Height: 20, PixelWidth: 60, PixelHeight: 40},
+		{Name: "right", X: 60, Y: 0, Width: 30, Height: 20, PixelWidth: 30, PixelHeight: 20},
+	}
+	sliced, err := pipeline.Span(source, pipeline.Pipeline{}, outputs)
+	if err != nil {
+		t.Fatalf("Span() failed: %v", err)
+	}
+
+	for i, output := range outputs {
+		slice := readImage(t, sliced[output.Name])
+		if got := slice.Bounds().Size(); got != image.Pt(output.PixelWidth, output.PixelHeight) {
+			t.Errorf("slice of %s is %v, want %dx%d", output.Name, got, output.PixelWidth, output.PixelHeight)
+		}
+		center := image.Pt(output.PixelWidth/2, output.PixelHeight/2)
+		if !near(slice, center.X, center.Y, stripes[i]) {
+			t.Errorf("slice of %s is %v, want %v", output.Name, slice.At(center.X, center.Y), stripes[i])
+		}
+	}
+}
+
+func TestSpanInvalid(t *testing.T) {
+	testutil.Home(t)
+	if _, err := pipeline.Span("wide.png", pipeline.Pipeline{}, nil); err == nil {
+		t.Error("Span() without outputs succeeded")
+	}
+	outputs := []pipeline.Output{{Name: "DP-1", Width: 10, Height: 10}}
+	if _, err := pipeline.Span("wide.png", pipeline.Pipeline{}, outputs); err == nil {
+		t.Error("Span() with an output without resolution succeeded")
+	}
+}
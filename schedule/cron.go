@@ -0,0 +1,124 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cron is a parsed five field cron expression: minute, hour, day of month,
+// month and day of week. Each field holds the set of matching values.
+type cron struct {
+	minute, hour, day, month, weekday uint64
+	// anyDay and anyWeekday record a day of month or day of week starting
+	// with "*", "*/2" included: as in cron, when both are restricted a time
+	// matching either one matches.
+	anyDay, anyWeekday bool
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+func parseCron(expr string) (cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cron{}, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var c cron
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return cron{}, fmt.Errorf("invalid cron minute %q: %w", fields[0], err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return cron{}, fmt.Errorf("invalid cron hour %q: %w", fields[1], err)
+	}
+	if c.day, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return cron{}, fmt.Errorf("invalid cron day of month %q: %w", fields[2], err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return cron{}, fmt.Errorf("invalid cron month %q: %w", fields[3], err)
+	}
+	// Sunday is both 0 and 7.
+	if c.weekday, err = parseField(fields[4], 0, 7, weekdayNames); err != nil {
+		return cron{}, fmt.Errorf("invalid cron day of week %q: %w", fields[4], err)
+	}
+	if c.weekday&(1<<7) != 0 {
+		c.weekday |= 1
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*")
+	c.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parseField parses a comma separated list of values, ranges (a-b) and steps
+// (*/n or a-b/n) between low and high. names, if any, are accepted for the
+// values from low on.
+func parseField(field string, low, high int, names []string) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+
+		start, end := low, high
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			start, err = parseValue(first, low, high, names)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				end, err = parseValue(last, low, high, names)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = high
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(text string, low, high int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(text, name) {
+			return low + i, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < low || v > high {
+		return 0, fmt.Errorf("invalid value %q (expected %d to %d)", text, low, high)
+	}
+	return v, nil
+}
+
+// matches reports whether the minute of t matches c.
+func (c cron) matches(t time.Time) bool {
+	if c.minute&(1<<t.Minute()) == 0 || c.hour&(1<<t.Hour()) == 0 || c.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	day := c.day&(1<<t.Day()) != 0
+	weekday := c.weekday&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
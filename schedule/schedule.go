@@ -0,0 +1,165 @@
+// Package schedule picks the wallpapers to draw from depending on the time:
+// each rule of a schedule covers some times of the week, with a cron
+// expression or a daily window, and names the directories and tags of its
+// wallpapers.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// horizon is how far ahead Next looks for a change of rule.
+const horizon = 8 * 24 * time.Hour
+
+// Rule maps some times of the week to a pool of wallpapers.
+type Rule struct {
+	Name string `yaml:"name,omitempty"`
+	// Cron is active during every minute matching the expression, e.g.
+	// "* 6-11 * * mon-fri" for weekday mornings.
+	Cron string `yaml:"cron,omitempty"`
+	// From and To, as HH:MM, are a daily window; To is excluded and the
+	// window goes past midnight when it is before From.
+	From string `yaml:"from,omitempty"`
+	To   string `yaml:"to,omitempty"`
+	// Days restricts the window to the days it starts on, e.g. [sat, sun].
+	Days []string `yaml:"days,omitempty"`
+	// Directories, Tags and Playlist select the wallpapers of the rule,
+	// see engine.Pool.
+	Directories []string `yaml:"directories,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Playlist    string   `yaml:"playlist,omitempty"`
+}
+
+// Schedule is a list of rules, the first active one wins. When none is
+// active every wallpaper can be picked.
+type Schedule []Rule
+
+// Label names the i-th rule of the schedule.
+func (s Schedule) Label(i int) string {
+	if i < 0 {
+		return "none"
+	}
+	if s[i].Name != "" {
+		return s[i].Name
+	}
+	return fmt.Sprintf("rule %d", i+1)
+}
+
+// Validate checks the times and pools of every rule.
+func (s Schedule) Validate() error {
+	for i, rule := range s {
+		if _, err := rule.compile(); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.Label(i), err)
+		}
+		if len(rule.Directories) == 0 && len(rule.Tags) == 0 && rule.Playlist == "" {
+			return fmt.Errorf("schedule %s: no directories, tags or playlist", s.Label(i))
+		}
+	}
+	return nil
+}
+
+// Active returns the index of the rule active at now, -1 if none is.
+// Invalid rules are never active.
+func (s Schedule) Active(now time.Time) int {
+	matchers := s.compile()
+	return active(matchers, now)
+}
+
+// Next returns the index of the rule that becomes active after now, -1 for
+// none, and when. It reports false when the active rule does not change in
+// the next week.
+func (s Schedule) Next(now time.Time) (int, time.Time, bool) {
+	matchers := s.compile()
+	minute := now.Truncate(time.Minute)
+	current := active(matchers, minute)
+	for t := minute.Add(time.Minute); t.Sub(minute) <= horizon; t = t.Add(time.Minute) {
+		if i := active(matchers, t); i != current {
+			return i, t, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+func (s Schedule) compile() []func(time.Time) bool {
+	matchers := make([]func(time.Time) bool, len(s))
+	for i, rule := range s {
+		match, err := rule.compile()
+		if err != nil {
+			match = func(time.Time) bool { return false }
+		}
+		matchers[i] = match
+	}
+	return matchers
+}
+
+func active(matchers []func(time.Time) bool, t time.Time) int {
+	for i, match := range matchers {
+		if match(t) {
+			return i
+		}
+	}
+	return -1
+}
+
+// compile returns a function reporting whether the rule is active at a time.
+func (r Rule) compile() (func(time.Time) bool, error) {
+	window := r.From != "" || r.To != "" || len(r.Days) > 0
+	if r.Cron != "" && window {
+		return nil, fmt.Errorf("cron cannot be combined with from, to and days")
+	}
+	if r.Cron != "" {
+		c, err := parseCron(r.Cron)
+		if err != nil {
+			return nil, err
+		}
+		return c.matches, nil
+	}
+	if r.From == "" || r.To == "" {
+		return nil, fmt.Errorf("expected cron or both from and to")
+	}
+
+	from, err := parseClock(r.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseClock(r.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == to || from == 24*60 {
+		return nil, fmt.Errorf("empty window from %s to %s", r.From, r.To)
+	}
+	days, err := parseField(strings.Join(r.Days, ","), 0, 6, weekdayNames)
+	if len(r.Days) == 0 {
+		days, err = 1<<7-1, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid days %v: %w", r.Days, err)
+	}
+
+	return func(t time.Time) bool {
+		minute := t.Hour()*60 + t.Minute()
+		weekday := int(t.Weekday())
+		if from < to {
+			return from <= minute && minute < to && days&(1<<weekday) != 0
+		}
+		// The window goes past midnight: after midnight it belongs to the
+		// day before.
+		if minute >= from {
+			return days&(1<<weekday) != 0
+		}
+		return minute < to && days&(1<<((weekday+6)%7)) != 0
+	}, nil
+}
+
+// parseClock returns the minutes since midnight of an HH:MM time, up to 24:00.
+func parseClock(text string) (int, error) {
+	var hour, minute int
+	_, err := fmt.Sscanf(text, "%d:%d", &hour, &minute)
+	if err != nil || len(text) != 5 || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", text)
+	}
+	return hour*60 + minute, nil
+}
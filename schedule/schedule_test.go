@@ -0,0 +1,144 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/schedule"
+)
+
+// at returns the given time of the week starting on Monday 2026-10-12.
+func at(weekday time.Weekday, hour, minute int) time.Time {
+	return time.Date(2026, 10, 12+(int(weekday)+6)%7, hour, minute, 0, 0, time.Local)
+}
+
+func TestActive(t *testing.T) {
+	s := schedule.Schedule{
+		{Name: "morning", From: "06:00", To: "12:00", Days: []string{"mon-fri"}, Tags: []string{"calm"}},
+		{Name: "night", From: "22:00", To: "06:00", Directories: []string{"/walls/night"}},
+		{Name: "weekend", Cron: "* 8-19 * * sat,sun", Tags: []string{"bright"}},
+		{Name: "new year", Cron: "* * 1 jan *", Tags: []string{"party"}},
+		{Name: "every ten minutes", Cron: "*/10 14 * * 1", Tags: []string{"flash"}},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"weekday morning", at(time.Monday, 6, 0), 0},
+		{"end of the window is excluded", at(time.Monday, 12, 0), -1},
+		{"weekday afternoon", at(time.Wednesday, 15, 30), -1},
+		{"saturday morning", at(time.Saturday, 9, 0), 2},
+		{"night before midnight", at(time.Friday, 23, 0), 1},
+		{"night after midnight", at(time.Saturday, 5, 59), 1},
+		{"weekend evening", at(time.Sunday, 20, 0), -1},
+		{"cron step", at(time.Monday, 14, 20), 4},
+		{"cron step miss", at(time.Monday, 14, 21), -1},
+		{"cron day of month", time.Date(2027, 1, 1, 15, 0, 0, 0, time.Local), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Active(tt.now); got != tt.want {
+				t.Errorf("Active(%s) = %s, want %s", tt.now.Format("Mon 15:04"), s.Label(got), s.Label(tt.want))
+			}
+		})
+	}
+}
+
+func TestActiveCronSteps(t *testing.T) {
+	s := schedule.Schedule{
+		{Name: "every other day", Cron: "0 9 * * */2", Tags: []string{"odd"}},
+		// A day of week step is not a restriction, both days must match.
+		{Name: "first", Cron: "0 10 1 * */2", Tags: []string{"first"}},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"sunday", at(time.Sunday, 9, 0), 0},
+		{"monday", at(time.Monday, 9, 0), -1},
+		{"tuesday", at(time.Tuesday, 9, 0), 0},
+		{"saturday", at(time.Saturday, 9, 0), 0},
+		{"first of the month on a thursday", time.Date(2026, 10, 1, 10, 0, 0, 0, time.Local), 1},
+		{"first of the month on a friday", time.Date(2027, 1, 1, 10, 0, 0, 0, time.Local), -1},
+		{"tuesday is not the first", at(time.Tuesday, 10, 0), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Active(tt.now); got != tt.want {
+				t.Errorf("Active(%s) = %s, want %s", tt.now.Format("Mon 2 15:04"), s.Label(got), s.Label(tt.want))
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	s := schedule.Schedule{
+		{Name: "day", From: "07:30", To: "19:00", Tags: []string{"day"}},
+		{Name: "sunday", Cron: "* * * * sun", Tags: []string{"lazy"}},
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		want   int
+		wantAt time.Time
+	}{
+		{"before the day", at(time.Monday, 6, 45), 0, at(time.Monday, 7, 30)},
+		{"during the day", at(time.Monday, 8, 15), -1, at(time.Monday, 19, 0)},
+		{"saturday night", at(time.Saturday, 21, 0), 1, at(time.Sunday, 0, 0)},
+		{"first rule wins", at(time.Sunday, 12, 0), 1, at(time.Sunday, 19, 0)},
+		{"sunday evening", at(time.Sunday, 20, 0), -1, at(time.Sunday, 0, 0).AddDate(0, 0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, when, ok := s.Next(tt.now)
+			if !ok || got != tt.want || !when.Equal(tt.wantAt) {
+				t.Errorf("Next() = %s at %s (%v), want %s at %s", s.Label(got), when, ok, s.Label(tt.want), tt.wantAt)
+			}
+		})
+	}
+
+	always := schedule.Schedule{{Cron: "* * * * *", Tags: []string{"all"}}}
+	if _, _, ok := always.Next(at(time.Monday, 0, 0)); ok {
+		t.Error("Next() found a change in a schedule that never changes")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    schedule.Rule
+		wantErr bool
+	}{
+		{"window", schedule.Rule{From: "20:00", To: "24:00", Days: []string{"Fri", "6"}, Tags: []string{"a"}}, false},
+		{"cron", schedule.Rule{Cron: "0-30/5 9 1-15 * *", Directories: []string{"/a"}}, false},
+		{"no pool", schedule.Rule{Cron: "* * * * *"}, true},
+		{"no time", schedule.Rule{Tags: []string{"a"}}, true},
+		{"missing to", schedule.Rule{From: "20:00", Tags: []string{"a"}}, true},
+		{"cron and window", schedule.Rule{Cron: "* * * * *", From: "20:00", To: "21:00", Tags: []string{"a"}}, true},
+		{"invalid time", schedule.Rule{From: "8:00", To: "21:00", Tags: []string{"a"}}, true},
+		{"empty window", schedule.Rule{From: "08:00", To: "08:00", Tags: []string{"a"}}, true},
+		{"invalid day", schedule.Rule{From: "08:00", To: "09:00", Days: []string{"someday"}, Tags: []string{"a"}}, true},
+		{"short cron", schedule.Rule{Cron: "* * *", Tags: []string{"a"}}, true},
+		{"cron out of range", schedule.Rule{Cron: "* 24 * * *", Tags: []string{"a"}}, true},
+		{"cron invalid step", schedule.Rule{Cron: "*/0 * * * *", Tags: []string{"a"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schedule.Schedule{tt.rule}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package sun computes sunrise and sunset offline from a latitude and a
+// longitude, with the sunrise equation. Times are within a few minutes,
+// enough to tell day from night.
+package sun
+
+import (
+	"math"
+	"time"
+)
+
+// Day and Night are the periods Period returns.
+const (
+	Day   = "day"
+	Night = "night"
+)
+
+// Location is a place on Earth, in degrees, north and east being positive.
+type Location struct {
+	Latitude  float64 `yaml:"latitude"`
+	Longitude float64 `yaml:"longitude"`
+}
+
+// j2000 is the Julian date of 2000-01-01 12:00 UTC.
+const j2000 = 2451545.0
+
+// Times returns the sunrise and the sunset of the day of date (in its
+// location). Around the poles the sun may not rise or set: ok is false and
+// up tells whether it stays up all day.
+func (l Location) Times(date time.Time) (sunrise, sunset time.Time, up, ok bool) {
+	// The noon of the day fixes which solar transit is meant.
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location())
+	n := math.Round(julian(noon) - j2000 - 0.0008)
+
+	transitDay := n - l.Longitude/360
+	anomaly := mod360(357.5291 + 0.98560028*transitDay)
+	m := radians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.02*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	ecliptic := radians(mod360(anomaly + center + 180 + 102.9372))
+	transit := j2000 + transitDay + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*ecliptic)
+
+	declination := math.Asin(math.Sin(ecliptic) * math.Sin(radians(23.4397)))
+	latitude := radians(l.Latitude)
+	// -0.833 degrees accounts for refraction and the size of the sun.
+	cosHour := (math.Sin(radians(-0.833)) - math.Sin(latitude)*math.Sin(declination)) /
+		(math.Cos(latitude) * math.Cos(declination))
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, cosHour < -1, false
+	}
+
+	hour := math.Acos(cosHour) / (2 * math.Pi)
+	loc := date.Location()
+	return fromJulian(transit - hour).In(loc), fromJulian(transit + hour).In(loc), true, true
+}
+
+// solar returns t in the mean solar time of the location, so that its
+// calendar day is the one of the sun there whatever the zone of the clock.
+func (l Location) solar(t time.Time) time.Time {
+	return t.In(time.FixedZone("solar", int(math.Round(l.Longitude*240))))
+}
+
+// Period returns Day or Night at now.
+func (l Location) Period(now time.Time) string {
+	sunrise, sunset, up, ok := l.Times(l.solar(now))
+	if !ok {
+		if up {
+			return Day
+		}
+		return Night
+	}
+	if !now.Before(sunrise) && now.Before(sunset) {
+		return Day
+	}
+	return Night
+}
+
+// Next returns when the period changes after now. It reports false when it
+// does not within a year, which only happens at the poles.
+func (l Location) Next(now time.Time) (time.Time, bool) {
+	period := l.Period(now)
+	for days := range 367 {
+		date := l.solar(now).AddDate(0, 0, days)
+		sunrise, sunset, up, ok := l.Times(date)
+		if !ok {
+			if (up && period == Night) || (!up && period == Day) {
+				// The sun rose or set at the start of a polar day or night.
+				start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+				return start.In(now.Location()), true
+			}
+			continue
+		}
+		if period == Night && sunrise.After(now) {
+			return sunrise.In(now.Location()), true
+		}
+		if period == Day && sunset.After(now) {
+			return sunset.In(now.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-2440587.5)*86400)), 0)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func mod360(degrees float64) float64 {
+	return math.Mod(math.Mod(degrees, 360)+360, 360)
+}
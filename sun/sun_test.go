@@ -0,0 +1,122 @@
+package sun_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/wallman/sun"
+)
+
+func zone(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+// near reports whether got is within 5 minutes of the HH:MM want.
+func near(got time.Time, want string) bool {
+	clock, err := time.Parse("15:04", want)
+	if err != nil {
+		return false
+	}
+	minutes := got.Hour()*60 + got.Minute() - (clock.Hour()*60 + clock.Minute())
+	return -5 <= minutes && minutes <= 5
+}
+
+func TestTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		location sun.Location
+		date     time.Time
+		sunrise  string
+		sunset   string
+	}{
+		{"Milan summer", sun.Location{Latitude: 45.46, Longitude: 9.19}, time.Date(2026, 6, 21, 9, 0, 0, 0, zone(t, "Europe/Rome")), "05:35", "21:15"},
+		{"London winter", sun.Location{Latitude: 51.51, Longitude: -0.13}, time.Date(2026, 12, 21, 23, 0, 0, 0, zone(t, "Europe/London")), "08:04", "15:54"},
+		{"Sydney", sun.Location{Latitude: -33.87, Longitude: 151.21}, time.Date(2026, 1, 15, 1, 0, 0, 0, zone(t, "Australia/Sydney")), "05:58", "20:09"},
+		{"New York", sun.Location{Latitude: 40.71, Longitude: -74.01}, time.Date(2026, 3, 20, 12, 0, 0, 0, zone(t, "America/New_York")), "06:59", "19:10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sunrise, sunset, _, ok := tt.location.Times(tt.date)
+			if !ok {
+				t.Fatal("Times() found no sunrise")
+			}
+			if !near(sunrise, tt.sunrise) || !near(sunset, tt.sunset) {
+				t.Errorf("Times() = %s, %s, want about %s, %s", sunrise.Format("15:04"), sunset.Format("15:04"), tt.sunrise, tt.sunset)
+			}
+			if sunrise.YearDay() != tt.date.YearDay() || sunset.YearDay() != tt.date.YearDay() {
+				t.Errorf("Times() = %s, %s, not on %s", sunrise, sunset, tt.date.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	rome := zone(t, "Europe/Rome")
+	milan := sun.Location{Latitude: 45.46, Longitude: 9.19}
+	tromso := sun.Location{Latitude: 69.65, Longitude: 18.96}
+
+	tests := []struct {
+		name     string
+		location sun.Location
+		now      time.Time
+		want     string
+		next     time.Time
+	}{
+		{"morning", milan, time.Date(2026, 6, 21, 10, 0, 0, 0, rome), sun.Day, time.Date(2026, 6, 21, 21, 15, 0, 0, rome)},
+		{"before sunrise", milan, time.Date(2026, 6, 21, 4, 0, 0, 0, rome), sun.Night, time.Date(2026, 6, 21, 5, 35, 0, 0, rome)},
+		{"after sunset", milan, time.Date(2026, 6, 21, 22, 0, 0, 0, rome), sun.Night, time.Date(2026, 6, 22, 5, 35, 0, 0, rome)},
+		{"polar day", tromso, time.Date(2026, 6, 21, 23, 59, 0, 0, rome), sun.Day, time.Date(2026, 7, 26, 0, 0, 0, 0, rome)},
+		{"polar night", tromso, time.Date(2026, 12, 21, 12, 0, 0, 0, rome), sun.Night, time.Date(2027, 1, 15, 0, 0, 0, 0, rome)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.location.Period(tt.now); got != tt.want {
+				t.Errorf("Period() = %s, want %s", got, tt.want)
+			}
+			next, ok := tt.location.Next(tt.now)
+			if !ok {
+				t.Fatal("Next() found no change")
+			}
+			if diff := next.Sub(tt.next).Abs(); diff > 2*24*time.Hour || (tt.location == milan && diff > 5*time.Minute) {
+				t.Errorf("Next() = %s, want about %s", next, tt.next)
+			}
+			if tt.location.Period(next) == tt.want {
+				t.Errorf("Period(Next()) = %s, want a change", tt.want)
+			}
+		})
+	}
+}
+
+// TestPeriodClockZone checks that the clock zone does not matter, even when
+// it is a day off the location.
+func TestPeriodClockZone(t *testing.T) {
+	sydney := sun.Location{Latitude: -33.87, Longitude: 151.21}
+	local := zone(t, "Australia/Sydney")
+
+	tests := []struct {
+		now  time.Time
+		want string
+	}{
+		{time.Date(2026, 1, 15, 20, 0, 0, 0, time.UTC), sun.Day},
+		{time.Date(2026, 1, 15, 23, 0, 0, 0, time.UTC), sun.Day},
+		{time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC), sun.Night},
+		{time.Date(2026, 1, 15, 18, 0, 0, 0, time.UTC), sun.Night},
+	}
+	for _, tt := range tests {
+		for _, now := range []time.Time{tt.now, tt.now.In(local)} {
+			if got := sydney.Period(now); got != tt.want {
+				t.Errorf("Period(%s) = %s, want %s", now, got, tt.want)
+			}
+			next, ok := sydney.Next(now)
+			if !ok || !next.After(now) || next.Sub(now) > 24*time.Hour || sydney.Period(next) == tt.want {
+				t.Errorf("Next(%s) = %s, %v, want the next change", now, next, ok)
+			}
+		}
+	}
+}
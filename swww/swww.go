@@ -0,0 +1,95 @@
+// Package swww provides wallpaper management for Wayland compositors using the swww daemon.
+package swww
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Options are the swww settings exposed in the wallman config.
+type Options struct {
+	// TransitionType is passed to --transition-type (simple, fade, wipe, grow, ...).
+	TransitionType string `yaml:"transition_type,omitempty"`
+	// TransitionDuration is the transition length in seconds.
+	TransitionDuration float64 `yaml:"transition_duration,omitempty"`
+	// TransitionFPS is the frame rate of the transition.
+	TransitionFPS int `yaml:"transition_fps,omitempty"`
+	// TransitionPos is where grow/outer transitions start, e.g. "center" or "0.5,0.5".
+	TransitionPos string `yaml:"transition_pos,omitempty"`
+}
+
+// Swww is an engine.Backend driving `swww img`.
+type Swww struct {
+	options Options
+}
+
+func New(options Options) *Swww {
+	return &Swww{options: options}
+}
+
+func (sw *Swww) Apply(path, fit string, outputs []string) error {
+	err := exec.Command("swww", imgArgs(path, fit, outputs, sw.options)...).Run()
+	if err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+	return nil
+}
+
+func (sw *Swww) Outputs() ([]string, error) {
+	out, err := exec.Command("swww", "query").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swww outputs: %w", err)
+	}
+	return parseQuery(string(out)), nil
+}
+
+// imgArgs builds the arguments of `swww img`. No outputs targets all of them.
+func imgArgs(path, fit string, outputs []string, options Options) []string {
+	args := []string{"img", path, "--resize", resizeMode(fit)}
+	if len(outputs) > 0 {
+		args = append(args, "--outputs", strings.Join(outputs, ","))
+	}
+	if options.TransitionType != "" {
+		args = append(args, "--transition-type", options.TransitionType)
+	}
+	if options.TransitionDuration > 0 {
+		args = append(args, "--transition-duration", strconv.FormatFloat(options.TransitionDuration, 'f', -1, 64))
+	}
+	if options.TransitionFPS > 0 {
+		args = append(args, "--transition-fps", strconv.Itoa(options.TransitionFPS))
+	}
+	if options.TransitionPos != "" {
+		args = append(args, "--transition-pos", options.TransitionPos)
+	}
+	return args
+}
+
+// resizeMode maps the hyprpaper style fit modes onto swww's --resize values.
+func resizeMode(fit string) string {
+	switch fit {
+	case "contain":
+		return "fit"
+	case "tile":
+		return "no"
+	default:
+		return "crop"
+	}
+}
+
+// parseQuery extracts the output names from `swww query`. Depending on the
+// swww version lines look like ": DP-1: 2560x1440, scale: 1, ..." or
+// "DP-1: 2560x1440, scale: 1, ...".
+func parseQuery(out string) []string {
+	var names []string
+	for line := range strings.Lines(out) {
+		line = strings.TrimPrefix(strings.TrimSpace(line), ":")
+		name, _, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found || name == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+package swww
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{
+			name: "leading colon",
+			out: `: DP-1: 2560x1440, scale: 1, currently displaying: image: /walls/a.png
+: HDMI-A-1: 1920x1080, scale: 1, currently displaying: color: 000000
+`,
+			want: []string{"DP-1", "HDMI-A-1"},
+		},
+		{
+			name: "without leading colon",
+			out:  "eDP-1: 1920x1200, scale: 1.5, currently displaying: image: /walls/b.png\n",
+			want: []string{"eDP-1"},
+		},
+		{
+			name: "empty",
+			out:  "",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseQuery(tt.out)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImgArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []string
+		fit     string
+		options Options
+		want    []string
+	}{
+		{
+			name: "all outputs with defaults",
+			fit:  "cover",
+			want: []string{"img", "/walls/a.png", "--resize", "crop"},
+		},
+		{
+			name:    "two outputs with transition",
+			outputs: []string{"DP-1", "HDMI-A-1"},
+			fit:     "contain",
+			options: Options{TransitionType: "grow", TransitionDuration: 1.5, TransitionFPS: 60, TransitionPos: "center"},
+			want: []string{
+				"img", "/walls/a.png", "--resize", "fit", "--outputs", "DP-1,HDMI-A-1",
+				"--transition-type", "grow", "--transition-duration", "1.5",
+				"--transition-fps", "60", "--transition-pos", "center",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := imgArgs("/walls/a.png", tt.fit, tt.outputs, tt.options)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("imgArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}